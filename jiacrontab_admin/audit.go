@@ -0,0 +1,423 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"jiacrontab/models"
+	"jiacrontab/pkg/proto"
+	"time"
+
+	"github.com/kataras/iris"
+)
+
+// SubmitJobForAuditReqParams /submitJobForAudit 请求参数
+type SubmitJobForAuditReqParams struct {
+	Addr    string `json:"addr" valid:"required"`
+	JobType string `json:"jobType" valid:"required"`
+	JobID   uint   `json:"jobID" valid:"required"`
+}
+
+// ListPendingAuditsReqParams /listPendingAudits 请求参数
+type ListPendingAuditsReqParams struct {
+	Pagesize int `json:"pagesize"`
+	Page     int `json:"page"`
+}
+
+// DecideAuditReqParams /decideAudit 请求参数
+type DecideAuditReqParams struct {
+	RequestID uint   `json:"requestID" valid:"required"`
+	Decision  string `json:"decision" valid:"required"` // approve/reject
+	Comment   string `json:"comment" valid:"required"`
+}
+
+// WithdrawAuditReqParams /withdrawAudit 请求参数
+type WithdrawAuditReqParams struct {
+	RequestID uint `json:"requestID" valid:"required"`
+}
+
+// SubmitJobForAudit 提交一个待生效的任务变更，进入多阶段审批流程，
+// 不再像旧版 AuditJob 那样直接下发到节点执行
+func SubmitJobForAudit(c iris.Context) {
+	var (
+		err     error
+		ctx     = wrapCtx(c)
+		reqBody SubmitJobForAuditReqParams
+	)
+
+	if err = ctx.Valid(&reqBody); err != nil {
+		ctx.respParamError(err)
+		return
+	}
+
+	if !ctx.verifyNodePermission(reqBody.Addr) {
+		ctx.respNotAllowed()
+		return
+	}
+
+	if ctx.has(models.CapJobCreateLimited) {
+		ctx.respNotAllowed()
+		return
+	}
+
+	snapshot, err := fetchJobSnapshot(reqBody.Addr, reqBody.JobType, reqBody.JobID)
+	if err != nil {
+		ctx.respRPCError(err)
+		return
+	}
+
+	req := models.JobAuditRequest{
+		Addr:            reqBody.Addr,
+		JobType:         reqBody.JobType,
+		JobID:           reqBody.JobID,
+		RequestedBy:     ctx.claims.UserID,
+		Snapshot:        snapshot,
+		CurrentSnapshot: snapshot,
+		Status:          models.JobAuditStatusPending,
+		Stage:           0,
+	}
+	if err = models.DB().Create(&req).Error; err != nil {
+		ctx.respDBError(err)
+		return
+	}
+
+	ctx.pubEvent("", event_SubmitJobForAudit, reqBody.Addr, req)
+	ctx.respSucc("", req)
+}
+
+// ListPendingAudits 列出当前用户所在分组/本人作为审核人待处理的审核请求，
+// 并附带服务端计算出的快照差异供前端展示
+func ListPendingAudits(c iris.Context) {
+	var (
+		err     error
+		ctx     = wrapCtx(c)
+		reqBody ListPendingAuditsReqParams
+		reqs    []models.JobAuditRequest
+	)
+
+	if err = ctx.Valid(&reqBody); err != nil {
+		ctx.respParamError(err)
+		return
+	}
+
+	if err = setClaimsFromAccessToken(c, ctx); err != nil {
+		ctx.respJWTError(err)
+		return
+	}
+
+	m := models.DB().Debug().Where("status=?", models.JobAuditStatusPending)
+	if !ctx.isSuper() {
+		addrs, err := ctx.getGroupAddr()
+		if err != nil {
+			ctx.respError(proto.Code_Error, err.Error(), nil)
+			return
+		}
+		m = m.Where("addr in (?)", addrs)
+	}
+	if err = m.Limit(reqBody.Pagesize).Find(&reqs).Error; err != nil {
+		ctx.respDBError(err)
+		return
+	}
+
+	type item struct {
+		models.JobAuditRequest
+		Diff []snapshotDiffEntry `json:"diff"`
+	}
+	list := make([]item, 0, len(reqs))
+	for _, r := range reqs {
+		if !ctx.isSuper() {
+			gid, err := models.FindGroupIDByAddr(r.Addr)
+			if err != nil {
+				continue
+			}
+			policy, err := models.FindAuditPolicy(gid)
+			if err != nil || r.Stage >= len(policy.Stages) || !stageReviewerAllowed(policy.Stages[r.Stage], ctx.claims) {
+				continue
+			}
+		}
+		diff := diffSnapshots(r.Snapshot, refreshCurrentSnapshot(&r))
+		list = append(list, item{r, diff})
+	}
+
+	ctx.respSucc("", map[string]interface{}{
+		"list": list,
+	})
+}
+
+// DecideAudit 审核人对某个阶段做出通过/驳回决定；只有最后一个阶段通过
+// 才会真正调用 CrontabJob.Audit/DaemonJob.Audit 下发到节点
+func DecideAudit(c iris.Context) {
+	var (
+		err     error
+		ctx     = wrapCtx(c)
+		reqBody DecideAuditReqParams
+		req     models.JobAuditRequest
+	)
+
+	if err = ctx.Valid(&reqBody); err != nil {
+		ctx.respParamError(err)
+		return
+	}
+
+	if reqBody.Decision != "approve" && reqBody.Decision != "reject" {
+		ctx.respParamError(errors.New("decision 只能是 approve 或 reject"))
+		return
+	}
+
+	if err = setClaimsFromAccessToken(c, ctx); err != nil {
+		ctx.respJWTError(err)
+		return
+	}
+
+	if ctx.has(models.CapReadOnly) {
+		ctx.respNotAllowed()
+		return
+	}
+
+	if err = models.DB().Take(&req, "id=?", reqBody.RequestID).Error; err != nil {
+		ctx.respDBError(err)
+		return
+	}
+
+	if req.Status != models.JobAuditStatusPending {
+		ctx.respError(proto.Code_Error, "该审核请求已结束", nil)
+		return
+	}
+
+	// 审批策略按请求所属节点的分组来定，而不是按决策人自己的分组 —
+	// 否则两个不同分组的审核人会用各自的策略去评判同一个请求
+	nodeGroupID, err := models.FindGroupIDByAddr(req.Addr)
+	if err != nil {
+		ctx.respDBError(err)
+		return
+	}
+
+	policy, err := models.FindAuditPolicy(nodeGroupID)
+	if err != nil {
+		ctx.respDBError(err)
+		return
+	}
+
+	if req.Stage >= len(policy.Stages) {
+		ctx.respError(proto.Code_Error, "审批策略与当前阶段不匹配", nil)
+		return
+	}
+	stage := policy.Stages[req.Stage]
+
+	if !ctx.isSuper() && !stageReviewerAllowed(stage, ctx.claims) {
+		ctx.respNotAllowed()
+		return
+	}
+
+	step := models.JobAuditStep{
+		RequestID:      req.ID,
+		StageIdx:       req.Stage,
+		ReviewerUserID: ctx.claims.UserID,
+		Decision:       reqBody.Decision,
+		Comment:        reqBody.Comment,
+		DecidedAt:      time.Now(),
+	}
+	if err = models.DB().Create(&step).Error; err != nil {
+		ctx.respDBError(err)
+		return
+	}
+
+	if reqBody.Decision == "reject" {
+		req.Status = models.JobAuditStatusRejected
+		models.DB().Save(&req)
+		ctx.pubEvent("", event_RejectJobAudit, req.Addr, req)
+		ctx.respSucc("", req)
+		return
+	}
+
+	approved, err := stageQuorumReached(req.ID, req.Stage, stage)
+	if err != nil {
+		ctx.respDBError(err)
+		return
+	}
+	if !approved {
+		// 未达到本阶段法定人数，请求继续留在当前阶段等待其余审核人表态
+		ctx.respSucc("", req)
+		return
+	}
+
+	if req.Stage+1 >= len(policy.Stages) {
+		if err = dispatchJobAudit(req.Addr, req.JobType, []uint{req.JobID}); err != nil {
+			ctx.respRPCError(err)
+			return
+		}
+		req.Status = models.JobAuditStatusApproved
+	} else {
+		req.Stage++
+	}
+
+	if err = models.DB().Save(&req).Error; err != nil {
+		ctx.respDBError(err)
+		return
+	}
+
+	ctx.pubEvent("", event_DecideJobAudit, req.Addr, req)
+	ctx.respSucc("", req)
+}
+
+// stageReviewerAllowed 判断当前用户是否是这一审批阶段策略指定的审核人
+// （按组或按用户二选一命中即可）
+func stageReviewerAllowed(stage models.AuditPolicyStage, claims CustomerClaims) bool {
+	for _, gid := range stage.ReviewerGroupIDs {
+		if gid == claims.GroupID {
+			return true
+		}
+	}
+	for _, uid := range stage.ReviewerUserIDs {
+		if uid == claims.UserID {
+			return true
+		}
+	}
+	return false
+}
+
+// stageQuorumReached 统计当前阶段已经做出的通过决定数，与策略里配置
+// 的 Quorum 比较；Quorum<=0 时按 any-of（1 人即可）处理
+func stageQuorumReached(requestID uint, stageIdx int, stage models.AuditPolicyStage) (bool, error) {
+	var approvals int
+	err := models.DB().Model(&models.JobAuditStep{}).
+		Where("request_id=? and stage_idx=? and decision=?", requestID, stageIdx, "approve").
+		Count(&approvals).Error
+	if err != nil {
+		return false, err
+	}
+
+	quorum := stage.Quorum
+	if quorum <= 0 {
+		quorum = 1
+	}
+	return approvals >= quorum, nil
+}
+
+// WithdrawAudit 提交人撤回尚未完成的审核请求
+func WithdrawAudit(c iris.Context) {
+	var (
+		err     error
+		ctx     = wrapCtx(c)
+		reqBody WithdrawAuditReqParams
+		req     models.JobAuditRequest
+	)
+
+	if err = ctx.Valid(&reqBody); err != nil {
+		ctx.respParamError(err)
+		return
+	}
+
+	if ctx.has(models.CapReadOnly) {
+		ctx.respNotAllowed()
+		return
+	}
+
+	if err = models.DB().Take(&req, "id=?", reqBody.RequestID).Error; err != nil {
+		ctx.respDBError(err)
+		return
+	}
+
+	if req.RequestedBy != ctx.claims.UserID && !ctx.isSuper() {
+		ctx.respNotAllowed()
+		return
+	}
+
+	if req.Status != models.JobAuditStatusPending {
+		ctx.respError(proto.Code_Error, "该审核请求已结束，无法撤回", nil)
+		return
+	}
+
+	req.Status = models.JobAuditStatusWithdrawn
+	if err = models.DB().Save(&req).Error; err != nil {
+		ctx.respDBError(err)
+		return
+	}
+
+	ctx.respSucc("", nil)
+}
+
+// dispatchJobAudit 是原 AuditJob 中直接下发审核的逻辑，现在只在审批流程
+// 的最后一个阶段通过后调用
+func dispatchJobAudit(addr, jobType string, jobIDs []uint) error {
+	if jobType == "crontab" {
+		var reply []models.CrontabJob
+		return rpcCall(addr, "CrontabJob.Audit", proto.AuditJobArgs{JobIDs: jobIDs}, &reply)
+	}
+	var reply []models.DaemonJob
+	return rpcCall(addr, "DaemonJob.Audit", proto.AuditJobArgs{JobIDs: jobIDs}, &reply)
+}
+
+// refreshCurrentSnapshot 重新拉取节点上任务的最新定义并落库为 CurrentSnapshot。
+// CurrentSnapshot 只在提交审核时写过一次，如果从不刷新，diffSnapshots 对比的
+// 就永远是同一份快照，结果恒为空；节点暂时拉取不到时退回已存的值，不阻塞列表展示
+func refreshCurrentSnapshot(req *models.JobAuditRequest) string {
+	snapshot, err := fetchJobSnapshot(req.Addr, req.JobType, req.JobID)
+	if err != nil {
+		return req.CurrentSnapshot
+	}
+	if snapshot != req.CurrentSnapshot {
+		models.DB().Model(req).Update("current_snapshot", snapshot)
+	}
+	req.CurrentSnapshot = snapshot
+	return snapshot
+}
+
+// fetchJobSnapshot 拉取节点上任务当前的完整定义，序列化为 JSON 作为审核快照
+func fetchJobSnapshot(addr, jobType string, jobID uint) (string, error) {
+	if jobType == "crontab" {
+		var reply models.CrontabJob
+		if err := rpcCall(addr, "CrontabJob.Get", jobID, &reply); err != nil {
+			return "", err
+		}
+		b, err := json.Marshal(reply)
+		return string(b), err
+	}
+	var reply models.DaemonJob
+	if err := rpcCall(addr, "DaemonJob.Get", jobID, &reply); err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(reply)
+	return string(b), err
+}
+
+// snapshotDiffEntry 快照中某一个字段的前后差异，供前端高亮展示
+type snapshotDiffEntry struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// diffSnapshots 对比审核请求提交时与当前的快照，逐字段返回差异
+func diffSnapshots(oldSnapshot, newSnapshot string) []snapshotDiffEntry {
+	var oldM, newM map[string]interface{}
+	if err := json.Unmarshal([]byte(oldSnapshot), &oldM); err != nil {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(newSnapshot), &newM); err != nil {
+		return nil
+	}
+
+	var diffs []snapshotDiffEntry
+	for field, oldVal := range oldM {
+		newVal, ok := newM[field]
+		if !ok {
+			continue
+		}
+		if !jsonEqual(oldVal, newVal) {
+			diffs = append(diffs, snapshotDiffEntry{Field: field, Old: oldVal, New: newVal})
+		}
+	}
+	return diffs
+}
+
+func jsonEqual(a, b interface{}) bool {
+	ab, _ := json.Marshal(a)
+	bb, _ := json.Marshal(b)
+	return string(ab) == string(bb)
+}
+
+const (
+	event_SubmitJobForAudit = "event_SubmitJobForAudit"
+	event_DecideJobAudit    = "event_DecideJobAudit"
+	event_RejectJobAudit    = "event_RejectJobAudit"
+)
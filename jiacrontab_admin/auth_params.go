@@ -0,0 +1,20 @@
+package admin
+
+// RefreshReqParams /refresh 请求参数
+type RefreshReqParams struct {
+	RefreshToken string `json:"refreshToken" valid:"required"`
+}
+
+// LogoutReqParams /logout 请求参数，refreshToken 可选，
+// 带上时会一并撤销对应的刷新令牌家族
+type LogoutReqParams struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+// RevokeUserReqParams /revokeUser 请求参数，仅超级管理员可调用
+type RevokeUserReqParams struct {
+	UserID uint `json:"userID" valid:"required"`
+}
+
+// event_RevokeUser 管理员强制撤销用户全部会话事件
+const event_RevokeUser = "event_RevokeUser"
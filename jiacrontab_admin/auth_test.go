@@ -0,0 +1,32 @@
+package admin
+
+import (
+	"jiacrontab/models"
+	"testing"
+)
+
+func TestAuditJobRequiresItsOwnCapability(t *testing.T) {
+	ctx := &Context{claims: CustomerClaims{GroupID: 2, Capabilities: models.CapJobCreateLimited}}
+	if ctx.can(models.CapAuditOnly, "addr") {
+		t.Fatal("CapJobCreateLimited must not satisfy the CapAuditOnly check AuditJob relies on")
+	}
+}
+
+func TestLoginBlockedForDisabledSuper(t *testing.T) {
+	cases := []struct {
+		name    string
+		profile models.UserProfile
+		blocked bool
+	}{
+		{"disabled account", models.UserProfile{Status: models.UserStatusDisabled}, true},
+		{"normal account", models.UserProfile{Status: models.UserStatusNormal}, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := loginBlocked(&c.profile); got != c.blocked {
+				t.Fatalf("loginBlocked() = %v, want %v", got, c.blocked)
+			}
+		})
+	}
+}
@@ -0,0 +1,389 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"jiacrontab/jiacrontab_admin/bulkio"
+	"jiacrontab/models"
+	"jiacrontab/pkg/mail"
+	"jiacrontab/pkg/password"
+	"jiacrontab/pkg/proto"
+	"jiacrontab/pkg/util"
+	"strconv"
+
+	"github.com/kataras/iris"
+)
+
+func init() {
+	registerUserBulkIO()
+	registerCrontabJobBulkIO()
+	registerDaemonJobBulkIO()
+	registerNodeGroupBulkIO()
+}
+
+// registerUserBulkIO 注册 USERS 的导入/导出：列顺序 username,mail,groupID,root,password。
+// password 列留空时不会创建一个空密码/明文账号 —— 会生成一个随机初始密码
+// （哈希后落库，明文不保留）并签发一次性重置令牌，要求该用户走
+// RequestPasswordReset/ResetPassword 自助设置密码后才能登录
+func registerUserBulkIO() {
+	bulkio.RegisterImporter(bulkio.CodeUsers, []string{"username", "mail", "groupID", "root", "password"}, func(cells []string) error {
+		if len(cells) < 5 {
+			return errors.New("列数不足，应为 username,mail,groupID,root,password")
+		}
+		groupID, err := strconv.ParseUint(cells[2], 10, 64)
+		if err != nil {
+			return fmt.Errorf("groupID 非法: %v", err)
+		}
+
+		plainPasswd := cells[4]
+		forceReset := plainPasswd == ""
+		if forceReset {
+			plainPasswd = util.GenUUID()
+		} else if err = cfg.PasswordPolicy.Check(plainPasswd); err != nil {
+			return err
+		}
+
+		hashedPasswd, err := password.Hash(plainPasswd)
+		if err != nil {
+			return err
+		}
+
+		var user models.User
+		user.Username = cells[0]
+		user.Mail = cells[1]
+		user.GroupID = uint(groupID)
+		user.Root = cells[3] == "1" || cells[3] == "true"
+		user.Passwd = hashedPasswd
+		if err = user.Create(); err != nil {
+			return err
+		}
+
+		if forceReset {
+			token := util.GenUUID()
+			if rt, err := models.NewPasswordResetToken(user.ID, token, passwordResetTTL); err == nil {
+				_ = rt
+				mail.Send(user.Mail, "jiacrontab 密码重置",
+					fmt.Sprintf("管理员已为你创建账号，请在 30 分钟内使用以下令牌设置密码: %s", token))
+			}
+		}
+		return nil
+	})
+
+	bulkio.RegisterExporter(bulkio.CodeUsers, []bulkio.Column{
+		{Header: "username", Value: func(row interface{}) string { return row.(models.User).Username }},
+		{Header: "mail", Value: func(row interface{}) string { return row.(models.User).Mail }},
+		{Header: "groupID", Value: func(row interface{}) string { return fmt.Sprintf("%d", row.(models.User).GroupID) }},
+		{Header: "root", Value: func(row interface{}) string { return fmt.Sprintf("%t", row.(models.User).Root) }},
+	}, func(scope bulkio.ExportScope) ([]interface{}, error) {
+		var users []models.User
+		if err := models.DB().Find(&users).Error; err != nil {
+			return nil, err
+		}
+		rows := make([]interface{}, len(users))
+		for i, u := range users {
+			rows[i] = u
+		}
+		return rows, nil
+	})
+}
+
+// crontabJobExportRow/daemonJobExportRow 是导出 CRONTAB_JOBS/DAEMON_JOBS 时
+// 的一行：节点上的任务本身不落在中心库里，导出时要按 addr 逐个 rpcCall 拉取
+type crontabJobExportRow struct {
+	Addr    string
+	ID      uint
+	Name    string
+	Command string
+}
+
+type daemonJobExportRow struct {
+	Addr    string
+	ID      uint
+	Name    string
+	Command string
+}
+
+// registerCrontabJobBulkIO 注册 CRONTAB_JOBS 的导入/导出：列顺序 addr,jobID,name,command。
+// 导入按 addr 通过 rpcCall 下发到对应节点；导出按 scope 允许的节点逐个拉取任务列表
+func registerCrontabJobBulkIO() {
+	bulkio.RegisterImporter(bulkio.CodeCrontabJobs, []string{"addr", "jobID", "name", "command"}, func(cells []string) error {
+		if len(cells) < 4 {
+			return errors.New("列数不足，应为 addr,jobID,name,command")
+		}
+		jobID, err := strconv.ParseUint(cells[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("jobID 非法: %v", err)
+		}
+		var reply models.CrontabJob
+		return rpcCall(cells[0], "CrontabJob.Edit", proto.CrontabJobArgs{
+			ID:      uint(jobID),
+			Name:    cells[2],
+			Command: cells[3],
+		}, &reply)
+	})
+
+	bulkio.RegisterExporter(bulkio.CodeCrontabJobs, []bulkio.Column{
+		{Header: "addr", Value: func(row interface{}) string { return row.(crontabJobExportRow).Addr }},
+		{Header: "jobID", Value: func(row interface{}) string { return fmt.Sprintf("%d", row.(crontabJobExportRow).ID) }},
+		{Header: "name", Value: func(row interface{}) string { return row.(crontabJobExportRow).Name }},
+		{Header: "command", Value: func(row interface{}) string { return row.(crontabJobExportRow).Command }},
+	}, func(scope bulkio.ExportScope) ([]interface{}, error) {
+		addrs, err := exportableNodeAddrs(scope)
+		if err != nil {
+			return nil, err
+		}
+		var rows []interface{}
+		for _, addr := range addrs {
+			var reply []models.CrontabJob
+			if err := rpcCall(addr, "CrontabJob.List", struct{}{}, &reply); err != nil {
+				continue // 节点暂时不可达时跳过，不让整体导出失败
+			}
+			for _, j := range reply {
+				rows = append(rows, crontabJobExportRow{Addr: addr, ID: j.ID, Name: j.Name, Command: j.Command})
+			}
+		}
+		return rows, nil
+	})
+}
+
+// registerDaemonJobBulkIO 注册 DAEMON_JOBS 的导入/导出：列顺序 addr,jobID,name,command，
+// 与 CRONTAB_JOBS 一致，只是下发/拉取任务用的 RPC 方法不同
+func registerDaemonJobBulkIO() {
+	bulkio.RegisterImporter(bulkio.CodeDaemonJobs, []string{"addr", "jobID", "name", "command"}, func(cells []string) error {
+		if len(cells) < 4 {
+			return errors.New("列数不足，应为 addr,jobID,name,command")
+		}
+		jobID, err := strconv.ParseUint(cells[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("jobID 非法: %v", err)
+		}
+		var reply models.DaemonJob
+		return rpcCall(cells[0], "DaemonJob.Edit", proto.DaemonJobArgs{
+			ID:      uint(jobID),
+			Name:    cells[2],
+			Command: cells[3],
+		}, &reply)
+	})
+
+	bulkio.RegisterExporter(bulkio.CodeDaemonJobs, []bulkio.Column{
+		{Header: "addr", Value: func(row interface{}) string { return row.(daemonJobExportRow).Addr }},
+		{Header: "jobID", Value: func(row interface{}) string { return fmt.Sprintf("%d", row.(daemonJobExportRow).ID) }},
+		{Header: "name", Value: func(row interface{}) string { return row.(daemonJobExportRow).Name }},
+		{Header: "command", Value: func(row interface{}) string { return row.(daemonJobExportRow).Command }},
+	}, func(scope bulkio.ExportScope) ([]interface{}, error) {
+		addrs, err := exportableNodeAddrs(scope)
+		if err != nil {
+			return nil, err
+		}
+		var rows []interface{}
+		for _, addr := range addrs {
+			var reply []models.DaemonJob
+			if err := rpcCall(addr, "DaemonJob.List", struct{}{}, &reply); err != nil {
+				continue
+			}
+			for _, j := range reply {
+				rows = append(rows, daemonJobExportRow{Addr: addr, ID: j.ID, Name: j.Name, Command: j.Command})
+			}
+		}
+		return rows, nil
+	})
+}
+
+// exportableNodeAddrs 按 scope 返回本次导出允许覆盖的节点地址：超级管理员
+// 能看到全部节点，其余用户只能看到自己分组下的节点（与 GetJobHistory 的
+// ctx.getGroupAddr() 保持同一套范围）
+func exportableNodeAddrs(scope bulkio.ExportScope) ([]string, error) {
+	if scope.IsSuper {
+		var nodes []models.Node
+		if err := models.DB().Find(&nodes).Error; err != nil {
+			return nil, err
+		}
+		addrs := make([]string, len(nodes))
+		for i, n := range nodes {
+			addrs[i] = n.Addr
+		}
+		return addrs, nil
+	}
+	return scope.GroupAddrs, nil
+}
+
+// registerNodeGroupBulkIO 注册 NODE_GROUPS 的导入/导出：列顺序 addr,name,groupID，
+// 按 addr 归并到某个分组，addr 已存在则更新其分组和名称。导出按 scope 过滤，
+// 非超级管理员只能看到自己分组下的节点，不能像之前那样拿到全量节点清单
+func registerNodeGroupBulkIO() {
+	bulkio.RegisterImporter(bulkio.CodeNodeGroups, []string{"addr", "name", "groupID"}, func(cells []string) error {
+		if len(cells) < 3 {
+			return errors.New("列数不足，应为 addr,name,groupID")
+		}
+		groupID, err := strconv.ParseUint(cells[2], 10, 64)
+		if err != nil {
+			return fmt.Errorf("groupID 非法: %v", err)
+		}
+		node := models.Node{Addr: cells[0], Name: cells[1], GroupID: uint(groupID)}
+		return node.SetGroup()
+	})
+
+	bulkio.RegisterExporter(bulkio.CodeNodeGroups, []bulkio.Column{
+		{Header: "addr", Value: func(row interface{}) string { return row.(models.Node).Addr }},
+		{Header: "name", Value: func(row interface{}) string { return row.(models.Node).Name }},
+		{Header: "groupID", Value: func(row interface{}) string { return fmt.Sprintf("%d", row.(models.Node).GroupID) }},
+	}, func(scope bulkio.ExportScope) ([]interface{}, error) {
+		m := models.DB()
+		if !scope.IsSuper {
+			m = m.Where("addr in (?)", scope.GroupAddrs)
+		}
+		var nodes []models.Node
+		if err := m.Find(&nodes).Error; err != nil {
+			return nil, err
+		}
+		rows := make([]interface{}, len(nodes))
+		for i, n := range nodes {
+			rows[i] = n
+		}
+		return rows, nil
+	})
+}
+
+// BulkImport 通用批量导入入口：multipart 表单携带 code、xlsx/csv 文件和
+// 幂等用的 import_id；已处理过的 import_id 直接返回"已跳过"而不重复执行，
+// 响应里不带历史的逐行结果
+func BulkImport(c iris.Context) {
+	var (
+		err error
+		ctx = wrapCtx(c)
+	)
+
+	if err = setClaimsFromAccessToken(c, ctx); err != nil {
+		ctx.respJWTError(err)
+		return
+	}
+
+	if !ctx.isSuper() {
+		ctx.respNotAllowed()
+		return
+	}
+
+	code := bulkio.Code(c.FormValue("code"))
+	importID := c.FormValue("import_id")
+	if importID != "" {
+		if _, err := models.FindImportBatch(importID); err == nil {
+			ctx.respSucc("该批次已处理过，已跳过", nil)
+			return
+		}
+	}
+
+	file, info, err := c.FormFile("file")
+	if err != nil {
+		ctx.respParamError(err)
+		return
+	}
+	defer file.Close()
+
+	results, err := bulkio.Import(code, file, info.Filename)
+	if err != nil {
+		ctx.respError(proto.Code_Error, err.Error(), nil)
+		return
+	}
+
+	failed := 0
+	for _, r := range results {
+		if !r.OK {
+			failed++
+		}
+	}
+
+	if importID != "" {
+		batch := &models.ImportBatch{
+			Code:       string(code),
+			ImportID:   importID,
+			UserID:     ctx.claims.UserID,
+			TotalRows:  len(results),
+			FailedRows: failed,
+		}
+		if failed > 0 {
+			if report, err := bulkio.BuildErrorReport(results); err == nil {
+				batch.ErrorReport = report
+			}
+		}
+		models.CreateImportBatch(batch)
+	}
+
+	ctx.respSucc("", map[string]interface{}{
+		"results": results,
+		"failed":  failed,
+		"total":   len(results),
+	})
+}
+
+// BulkImportErrorReport 按 import_id 下载某次批量导入里失败行重新生成的 xlsx，
+// 只有失败行数大于 0 的批次才有报告可下载
+func BulkImportErrorReport(c iris.Context) {
+	var (
+		err error
+		ctx = wrapCtx(c)
+	)
+
+	if err = setClaimsFromAccessToken(c, ctx); err != nil {
+		ctx.respJWTError(err)
+		return
+	}
+
+	if !ctx.isSuper() {
+		ctx.respNotAllowed()
+		return
+	}
+
+	batch, err := models.FindImportBatch(c.URLParam("import_id"))
+	if err != nil {
+		ctx.respDBError(err)
+		return
+	}
+	if len(batch.ErrorReport) == 0 {
+		ctx.respError(proto.Code_Error, "该批次没有失败行，无错误报告", nil)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s_errors.xlsx", batch.ImportID))
+	c.ContentType("application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Write(batch.ErrorReport)
+}
+
+// BulkExport 通用批量导出入口：按 code 返回对应列定义渲染的 xlsx 文件
+func BulkExport(c iris.Context) {
+	var (
+		err error
+		ctx = wrapCtx(c)
+	)
+
+	if err = setClaimsFromAccessToken(c, ctx); err != nil {
+		ctx.respJWTError(err)
+		return
+	}
+
+	code := bulkio.Code(c.URLParam("code"))
+	isSuper := ctx.claims.GroupID == models.SuperGroup.ID
+	if code == bulkio.CodeUsers && !isSuper {
+		ctx.respNotAllowed()
+		return
+	}
+
+	groupAddrs, err := ctx.getGroupAddr()
+	if err != nil {
+		ctx.respError(proto.Code_Error, err.Error(), nil)
+		return
+	}
+
+	data, err := bulkio.Export(code, bulkio.ExportScope{
+		IsSuper:    isSuper,
+		GroupID:    ctx.claims.GroupID,
+		GroupAddrs: groupAddrs,
+	})
+	if err != nil {
+		ctx.respError(proto.Code_Error, err.Error(), nil)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.xlsx", code))
+	c.ContentType("application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Write(data)
+}
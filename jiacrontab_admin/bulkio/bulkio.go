@@ -0,0 +1,147 @@
+// Package bulkio 为 admin 包里按 code 区分的业务对象（用户、crontab 任务、
+// daemon 任务、节点分组）提供统一的 Excel/CSV 批量导入导出能力。每个
+// code 通过 RegisterImporter/RegisterExporter 注册自己的行解析器和导出列定义，subsystem 本身
+// 不关心具体业务字段。
+package bulkio
+
+import (
+	"fmt"
+	"mime/multipart"
+	"strings"
+)
+
+// Code 标识一种可批量导入导出的业务对象
+type Code string
+
+const (
+	CodeUsers       Code = "USERS"
+	CodeCrontabJobs Code = "CRONTAB_JOBS"
+	CodeDaemonJobs  Code = "DAEMON_JOBS"
+	CodeNodeGroups  Code = "NODE_GROUPS"
+)
+
+// RowResult 是单行导入的处理结果，汇总后返回给调用方并生成错误报告
+type RowResult struct {
+	Row   int    `json:"row"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// Column 描述导出时的一列：表头文案 + 取值函数
+type Column struct {
+	Header string
+	Value  func(row interface{}) string
+}
+
+// RowParser 把一行原始单元格解析并落库，返回该行是否成功
+type RowParser func(cells []string) error
+
+// ExportScope 描述发起导出请求的调用方权限范围，Exporter 据此决定
+// 返回该 code 下的全部数据还是按分组/节点过滤后的子集
+type ExportScope struct {
+	IsSuper    bool
+	GroupID    uint
+	GroupAddrs []string
+}
+
+// Exporter 返回某个 code 按 scope 过滤后待导出的记录
+type Exporter func(scope ExportScope) ([]interface{}, error)
+
+type codeSpec struct {
+	header  []string
+	parser  RowParser
+	columns []Column
+	export  Exporter
+}
+
+var registry = map[Code]*codeSpec{}
+
+// RegisterImporter 为一个 code 注册行解析器，header 是期望的表头顺序，
+// 用来在导入前校验上传文件的列是否匹配
+func RegisterImporter(code Code, header []string, parser RowParser) {
+	spec := registry[code]
+	if spec == nil {
+		spec = &codeSpec{}
+		registry[code] = spec
+	}
+	spec.header = header
+	spec.parser = parser
+}
+
+// RegisterExporter 为一个 code 注册导出列定义和数据来源
+func RegisterExporter(code Code, columns []Column, export Exporter) {
+	spec := registry[code]
+	if spec == nil {
+		spec = &codeSpec{}
+		registry[code] = spec
+	}
+	spec.columns = columns
+	spec.export = export
+}
+
+// Import 解析上传的 xlsx/csv 文件，逐行调用该 code 注册的解析器，
+// 返回每一行的处理结果；调用方负责将失败的行渲染成错误报告下载
+func Import(code Code, file multipart.File, filename string) ([]RowResult, error) {
+	spec, ok := registry[code]
+	if !ok || spec.parser == nil {
+		return nil, fmt.Errorf("未注册的批量导入类型: %s", code)
+	}
+
+	rows, err := readRows(file, filename)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	if len(spec.header) > 0 {
+		if len(rows[0]) < len(spec.header) {
+			return nil, fmt.Errorf("表头列数不足，应为 %s", strings.Join(spec.header, ","))
+		}
+		for i, h := range spec.header {
+			if !strings.EqualFold(strings.TrimSpace(rows[0][i]), h) {
+				return nil, fmt.Errorf("第 %d 列表头应为 %s，实际为 %s", i+1, h, rows[0][i])
+			}
+		}
+	}
+
+	results := make([]RowResult, 0, len(rows)-1)
+	for i, row := range rows[1:] {
+		rowNum := i + 2 // 第 1 行是表头
+		if err := spec.parser(row); err != nil {
+			results = append(results, RowResult{Row: rowNum, OK: false, Error: err.Error()})
+			continue
+		}
+		results = append(results, RowResult{Row: rowNum, OK: true})
+	}
+	return results, nil
+}
+
+// Export 生成某个 code 当前数据集（按 scope 过滤）对应的 xlsx 字节流
+func Export(code Code, scope ExportScope) ([]byte, error) {
+	spec, ok := registry[code]
+	if !ok || spec.export == nil {
+		return nil, fmt.Errorf("未注册的批量导出类型: %s", code)
+	}
+	rows, err := spec.export(scope)
+	if err != nil {
+		return nil, err
+	}
+	return writeXlsx(spec.columns, rows)
+}
+
+// BuildErrorReport 把导入失败行的行号和错误信息生成一份 xlsx；报告只有
+// "行号"、"错误" 两列，不包含失败行的原始单元格内容
+func BuildErrorReport(results []RowResult) ([]byte, error) {
+	columns := []Column{
+		{Header: "行号", Value: func(row interface{}) string { return fmt.Sprintf("%d", row.(RowResult).Row) }},
+		{Header: "错误", Value: func(row interface{}) string { return row.(RowResult).Error }},
+	}
+	rows := make([]interface{}, 0, len(results))
+	for _, r := range results {
+		if !r.OK {
+			rows = append(rows, r)
+		}
+	}
+	return writeXlsx(columns, rows)
+}
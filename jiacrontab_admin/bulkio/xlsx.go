@@ -0,0 +1,72 @@
+package bulkio
+
+import (
+	"bytes"
+	"encoding/csv"
+	"mime/multipart"
+	"path/filepath"
+	"strings"
+
+	"github.com/tealeg/xlsx"
+)
+
+// readRows 按文件扩展名选择 xlsx 或 csv 解析，统一返回 [][]string，
+// 第一行为表头
+func readRows(file multipart.File, filename string) ([][]string, error) {
+	if strings.EqualFold(filepath.Ext(filename), ".csv") {
+		r := csv.NewReader(file)
+		return r.ReadAll()
+	}
+
+	var buf bytes.Buffer
+	size, err := buf.ReadFrom(file)
+	if err != nil {
+		return nil, err
+	}
+
+	wb, err := xlsx.OpenBinary(buf.Bytes()[:size])
+	if err != nil {
+		return nil, err
+	}
+	if len(wb.Sheets) == 0 {
+		return nil, nil
+	}
+
+	sheet := wb.Sheets[0]
+	rows := make([][]string, 0, len(sheet.Rows))
+	for _, row := range sheet.Rows {
+		cells := make([]string, 0, len(row.Cells))
+		for _, cell := range row.Cells {
+			cells = append(cells, cell.String())
+		}
+		rows = append(rows, cells)
+	}
+	return rows, nil
+}
+
+// writeXlsx 按列定义渲染一个单 sheet 的 xlsx 文件
+func writeXlsx(columns []Column, rows []interface{}) ([]byte, error) {
+	wb := xlsx.NewFile()
+	sheet, err := wb.AddSheet("Sheet1")
+	if err != nil {
+		return nil, err
+	}
+
+	header := sheet.AddRow()
+	for _, col := range columns {
+		header.AddCell().Value = col.Header
+	}
+
+	for _, row := range rows {
+		sheetRow := sheet.AddRow()
+		for _, col := range columns {
+			sheetRow.AddCell().Value = col.Value(row)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := wb.Write(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
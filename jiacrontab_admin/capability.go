@@ -0,0 +1,145 @@
+package admin
+
+import (
+	"jiacrontab/models"
+
+	"github.com/kataras/iris"
+)
+
+// can 是粒度比 isSuper()/Root 更细的权限检查入口，供 admin 包内的 handler
+// 使用；Root 用户始终具备全部能力，其余用户按 CustomerClaims 里下发的
+// Capabilities 位图判断。addr 目前未参与判断，预留给未来按节点授权使用
+func (ctx *Context) can(cap models.Capability, addr string) bool {
+	if ctx.claims.Root {
+		return true
+	}
+	return ctx.claims.Capabilities&cap != 0
+}
+
+// canManageUsers 判断当前用户是否可以创建账号/调整账号分组：Super 分组
+// 下的用户和 Root 用户始终可以，其余用户需要被显式授予 CapUserManage
+func (ctx *Context) canManageUsers() bool {
+	return ctx.claims.GroupID == models.SuperGroup.ID || ctx.can(models.CapUserManage, "")
+}
+
+// has 是不看 Root 的原始能力位检查。can() 里 Root 恒为 true 的短路对
+// CapAuditOnly 这类"授予"语义的能力是对的，但 CapReadOnly/CapJobCreateLimited/
+// CapJobEditLimited/CapHistoryHidden 是反过来的"限制"语义，用 can() 判断
+// 会让每个 Root 账号都被当成命中了限制，所以这类能力一律用 has() 判断
+func (ctx *Context) has(cap models.Capability) bool {
+	return ctx.claims.Capabilities&cap != 0
+}
+
+// SetUserStatusReqParams /setUserStatus 请求参数
+type SetUserStatusReqParams struct {
+	UserID uint   `json:"userID" valid:"required"`
+	Status string `json:"status" valid:"required"` // normal/disabled
+}
+
+// GrantCapabilityReqParams /grantCapability、/revokeCapability 共用的请求参数
+type GrantCapabilityReqParams struct {
+	UserID     uint   `json:"userID" valid:"required"`
+	Capability uint32 `json:"capability" valid:"required"`
+}
+
+// SetUserStatus 管理员设置用户账号状态（如禁用一个账号）
+func SetUserStatus(c iris.Context) {
+	var (
+		err     error
+		ctx     = wrapCtx(c)
+		reqBody SetUserStatusReqParams
+	)
+
+	if err = ctx.Valid(&reqBody); err != nil {
+		ctx.respParamError(err)
+		return
+	}
+
+	if err = setClaimsFromAccessToken(c, ctx); err != nil {
+		ctx.respJWTError(err)
+		return
+	}
+
+	if !ctx.isSuper() {
+		ctx.respNotAllowed()
+		return
+	}
+
+	if err = models.SetUserStatus(reqBody.UserID, models.UserStatus(reqBody.Status)); err != nil {
+		ctx.respDBError(err)
+		return
+	}
+
+	ctx.pubEvent("", event_SetUserStatus, "", reqBody)
+	ctx.respSucc("", nil)
+}
+
+// GrantCapability 管理员为用户追加一项能力
+func GrantCapability(c iris.Context) {
+	var (
+		err     error
+		ctx     = wrapCtx(c)
+		reqBody GrantCapabilityReqParams
+	)
+
+	if err = ctx.Valid(&reqBody); err != nil {
+		ctx.respParamError(err)
+		return
+	}
+
+	if err = setClaimsFromAccessToken(c, ctx); err != nil {
+		ctx.respJWTError(err)
+		return
+	}
+
+	if !ctx.isSuper() {
+		ctx.respNotAllowed()
+		return
+	}
+
+	if err = models.GrantCapability(reqBody.UserID, models.Capability(reqBody.Capability)); err != nil {
+		ctx.respDBError(err)
+		return
+	}
+
+	ctx.pubEvent("", event_GrantCapability, "", reqBody)
+	ctx.respSucc("", nil)
+}
+
+// RevokeCapability 管理员移除用户的一项能力
+func RevokeCapability(c iris.Context) {
+	var (
+		err     error
+		ctx     = wrapCtx(c)
+		reqBody GrantCapabilityReqParams
+	)
+
+	if err = ctx.Valid(&reqBody); err != nil {
+		ctx.respParamError(err)
+		return
+	}
+
+	if err = setClaimsFromAccessToken(c, ctx); err != nil {
+		ctx.respJWTError(err)
+		return
+	}
+
+	if !ctx.isSuper() {
+		ctx.respNotAllowed()
+		return
+	}
+
+	if err = models.RevokeCapability(reqBody.UserID, models.Capability(reqBody.Capability)); err != nil {
+		ctx.respDBError(err)
+		return
+	}
+
+	ctx.pubEvent("", event_RevokeCapability, "", reqBody)
+	ctx.respSucc("", nil)
+}
+
+const (
+	event_SetUserStatus    = "event_SetUserStatus"
+	event_GrantCapability  = "event_GrantCapability"
+	event_RevokeCapability = "event_RevokeCapability"
+)
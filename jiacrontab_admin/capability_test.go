@@ -0,0 +1,48 @@
+package admin
+
+import (
+	"jiacrontab/models"
+	"testing"
+)
+
+func TestCanManageUsers(t *testing.T) {
+	cases := []struct {
+		name  string
+		ctx   Context
+		allow bool
+	}{
+		{
+			name:  "super group",
+			ctx:   Context{claims: CustomerClaims{GroupID: models.SuperGroup.ID}},
+			allow: true,
+		},
+		{
+			name:  "root",
+			ctx:   Context{claims: CustomerClaims{GroupID: 2, Root: true}},
+			allow: true,
+		},
+		{
+			name:  "granted CapUserManage",
+			ctx:   Context{claims: CustomerClaims{GroupID: 2, Capabilities: models.CapUserManage}},
+			allow: true,
+		},
+		{
+			name:  "regular user without capability",
+			ctx:   Context{claims: CustomerClaims{GroupID: 2}},
+			allow: false,
+		},
+		{
+			name:  "unrelated capability does not escalate",
+			ctx:   Context{claims: CustomerClaims{GroupID: 2, Capabilities: models.CapAuditOnly}},
+			allow: false,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.ctx.canManageUsers(); got != c.allow {
+				t.Fatalf("canManageUsers() = %v, want %v", got, c.allow)
+			}
+		})
+	}
+}
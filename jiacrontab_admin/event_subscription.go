@@ -0,0 +1,121 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"jiacrontab/models"
+	"net"
+	"net/url"
+
+	"github.com/kataras/iris"
+)
+
+// SetEventSubscriptionReqParams /setEventSubscription 请求参数
+type SetEventSubscriptionReqParams struct {
+	EventName  string `json:"eventName" valid:"required"`
+	Webhook    bool   `json:"webhook"`
+	WebhookURL string `json:"webhookURL"`
+	Mail       bool   `json:"mail"`
+}
+
+// ListEventSubscriptions 列出当前用户对各类事件的通知渠道偏好
+func ListEventSubscriptions(c iris.Context) {
+	var (
+		err error
+		ctx = wrapCtx(c)
+	)
+
+	if err = setClaimsFromAccessToken(c, ctx); err != nil {
+		ctx.respJWTError(err)
+		return
+	}
+
+	subs, err := models.FindEventSubscriptions(ctx.claims.UserID)
+	if err != nil {
+		ctx.respDBError(err)
+		return
+	}
+
+	ctx.respSucc("", map[string]interface{}{
+		"list": subs,
+	})
+}
+
+// SetEventSubscription 设置当前用户针对某一事件的通知渠道偏好
+func SetEventSubscription(c iris.Context) {
+	var (
+		err     error
+		ctx     = wrapCtx(c)
+		reqBody SetEventSubscriptionReqParams
+	)
+
+	if err = ctx.Valid(&reqBody); err != nil {
+		ctx.respParamError(err)
+		return
+	}
+
+	if err = setClaimsFromAccessToken(c, ctx); err != nil {
+		ctx.respJWTError(err)
+		return
+	}
+
+	if reqBody.Webhook {
+		if err = validateWebhookURL(reqBody.WebhookURL); err != nil {
+			ctx.respParamError(err)
+			return
+		}
+	}
+
+	sub := models.EventSubscription{
+		UserID:     ctx.claims.UserID,
+		EventName:  reqBody.EventName,
+		Webhook:    reqBody.Webhook,
+		WebhookURL: reqBody.WebhookURL,
+		Mail:       reqBody.Mail,
+	}
+	if err = models.SaveEventSubscription(&sub); err != nil {
+		ctx.respDBError(err)
+		return
+	}
+
+	ctx.respSucc("", sub)
+}
+
+// validateWebhookURL 防止任意登录用户把 webhookURL 设成内网地址，借
+// dispatchWebhook 的服务端出站请求做 SSRF 探测：只放行 http/https，且
+// 域名解析出的全部 IP 都必须是公网地址，命中私有/回环/链路本地一律拒绝
+func validateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("webhookURL 不合法: %v", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.New("webhookURL 只允许 http/https")
+	}
+	host := u.Hostname()
+	if host == "" {
+		return errors.New("webhookURL 缺少主机名")
+	}
+
+	ips, err := net.LookupIP(host)
+	if err != nil {
+		return fmt.Errorf("webhookURL 主机名无法解析: %v", err)
+	}
+	for _, ip := range ips {
+		if isReservedIP(ip) {
+			return errors.New("webhookURL 不允许指向内网/保留地址")
+		}
+	}
+	return nil
+}
+
+// isReservedIP 判断一个地址是否是回环/私有/链路本地/未指定/组播地址，
+// 这些都不该是一个对外 webhook 的投递目标
+func isReservedIP(ip net.IP) bool {
+	return ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsUnspecified() ||
+		ip.IsMulticast()
+}
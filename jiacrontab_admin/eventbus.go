@@ -0,0 +1,143 @@
+package admin
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"jiacrontab/models"
+	"jiacrontab/pkg/eventbus"
+	"jiacrontab/pkg/mail"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// bus 是进程内唯一的事件总线实例，initEventBus 在包初始化时装配内置订阅者
+var bus = eventbus.New(4)
+
+// eventCounters 是最简单的进程内指标计数器，按事件名累加次数
+var eventCounters = struct {
+	sync.Mutex
+	m map[string]uint64
+}{m: make(map[string]uint64)}
+
+func init() {
+	initEventBus()
+}
+
+// initEventBus 注册内置订阅者：持久化到 models.Event（供 GetActivityList 使用）
+// 和指标计数；webhook 分发和邮件通知不走总线订阅，由 publishEvent 按用户的
+// 订阅偏好直接发起
+func initEventBus() {
+	bus.Subscribe("*", persistEventSubscriber, eventbus.WithPriority(0))
+	bus.Subscribe("*", metricsEventSubscriber, eventbus.WithPriority(10), eventbus.WithAsync())
+}
+
+// metricsEventSubscriber 是最简单的进程内指标计数器订阅者，按事件名累加
+// eventCounters 中的次数
+func metricsEventSubscriber(e eventbus.Event) error {
+	eventCounters.Lock()
+	eventCounters.m[e.Name]++
+	eventCounters.Unlock()
+	return nil
+}
+
+// publishEvent 是新版 pubEvent 的核心实现：ctx.pubEvent 直接调用本函数把事件
+// 发布到总线（落库由总线上的 persistEventSubscriber 完成），再按该用户的订阅
+// 偏好异步发送 webhook/邮件
+func publishEvent(userID uint, target, name, addr string, payload interface{}) {
+	bus.Publish(eventbus.Event{
+		Name:    name,
+		UserID:  userID,
+		Addr:    addr,
+		Target:  target,
+		Payload: payload,
+		At:      time.Now(),
+	})
+
+	subs, err := models.FindEventSubscriptions(userID)
+	if err != nil {
+		return
+	}
+	for _, sub := range subs {
+		if sub.EventName != name {
+			continue
+		}
+		if sub.Webhook && sub.WebhookURL != "" {
+			go dispatchWebhook(sub.WebhookURL, name, payload)
+		}
+		if sub.Mail {
+			go notifyMail(userID, name, target, payload)
+		}
+	}
+}
+
+// persistEventSubscriber 是原先 pubEvent 里"写一条 models.Event"的逻辑，
+// 订阅通配符 "*" 以便任何事件都会被记录到活动列表
+func persistEventSubscriber(e eventbus.Event) error {
+	b, err := json.Marshal(e.Payload)
+	if err != nil {
+		return err
+	}
+	return models.DB().Create(&models.Event{
+		UserID:  e.UserID,
+		Addr:    e.Addr,
+		Name:    e.Name,
+		Content: string(b),
+	}).Error
+}
+
+// dispatchWebhook 以 HMAC-SHA256 对请求体签名后 POST 给用户配置的 URL，
+// 失败按指数退避重试，连同首次请求最多尝试 5 次
+func dispatchWebhook(url, eventName string, payload interface{}) {
+	body, err := json.Marshal(map[string]interface{}{
+		"event":   eventName,
+		"payload": payload,
+		"at":      time.Now().Unix(),
+	})
+	if err != nil {
+		return
+	}
+
+	sig := signWebhookBody(body)
+	backoff := time.Second
+	for attempt := 0; attempt < 5; attempt++ {
+		req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-Jiacrontab-Signature", sig)
+			resp, err := http.DefaultClient.Do(req)
+			if err == nil {
+				resp.Body.Close()
+				if resp.StatusCode < 300 {
+					return
+				}
+			}
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// signWebhookBody 用独立于 JWT 签名密钥的 cfg.Webhook.SigningKey 签名，
+// 避免把令牌签发密钥和外发集成的密钥混在一起：每个 webhook 接收方都要
+// 知道这个密钥才能验签，混用 Jwt.SigningKey 等于把它也泄露给了接收方
+func signWebhookBody(body []byte) string {
+	mac := hmac.New(sha256.New, []byte(cfg.Webhook.SigningKey))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// notifyMail 复用已有的 SMTP 配置向用户发送事件通知邮件
+func notifyMail(userID uint, eventName, target string, payload interface{}) {
+	var user models.User
+	if err := models.DB().Take(&user, "id=?", userID).Error; err != nil || user.Mail == "" {
+		return
+	}
+	subject := fmt.Sprintf("jiacrontab 事件通知: %s", eventName)
+	body := fmt.Sprintf("事件: %s\n对象: %s", eventName, target)
+	mail.Send(user.Mail, subject, body)
+}
@@ -0,0 +1,139 @@
+package admin
+
+import (
+	"errors"
+	"jiacrontab/models"
+	"strings"
+	"sync"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/kataras/iris"
+)
+
+// revokedJti 是进程内的访问令牌黑名单，用于在不查库的情况下快速拒绝
+// 已注销/已被管理员强制下线的令牌。启动时从 models.RevokedToken 加载，
+// Logout 时双写内存与数据库。RevokeUser 只撤销刷新令牌，不向这里写入。
+var revokedJti = struct {
+	sync.RWMutex
+	m map[string]time.Time
+}{m: make(map[string]time.Time)}
+
+func loadRevokedTokenDenylist() error {
+	tokens, err := models.LoadUnexpiredRevokedTokens()
+	if err != nil {
+		return err
+	}
+	revokedJti.Lock()
+	for _, t := range tokens {
+		revokedJti.m[t.Jti] = t.ExpiresAt
+	}
+	revokedJti.Unlock()
+	return nil
+}
+
+func revokeAccessToken(jti string, userID uint, expiresAt time.Time) error {
+	revokedJti.Lock()
+	revokedJti.m[jti] = expiresAt
+	revokedJti.Unlock()
+	return models.RevokeToken(jti, userID, expiresAt)
+}
+
+func isAccessTokenRevoked(jti string) bool {
+	revokedJti.RLock()
+	_, ok := revokedJti.m[jti]
+	revokedJti.RUnlock()
+	if ok {
+		return true
+	}
+	return models.IsTokenRevoked(jti)
+}
+
+// jwtSigningMethod 根据 cfg.Jwt.SigningMethod 选择签名算法，默认 HS256
+func jwtSigningMethod() jwt.SigningMethod {
+	switch cfg.Jwt.SigningMethod {
+	case "RS256":
+		return jwt.SigningMethodRS256
+	default:
+		return jwt.SigningMethodHS256
+	}
+}
+
+// jwtSigningKey 返回当前用于签发新令牌的密钥（HS256 为对称密钥字节，
+// RS256 为 *rsa.PrivateKey），以及随 kid 一起写入 header 的密钥标识，
+// 以便运维在不停机的情况下轮换密钥
+func jwtSigningKey() (interface{}, string, error) {
+	if len(cfg.Jwt.Keys) == 0 {
+		return []byte(cfg.Jwt.SigningKey), cfg.Jwt.Kid, nil
+	}
+	for _, k := range cfg.Jwt.Keys {
+		if k.Kid == cfg.Jwt.Kid {
+			if jwtSigningMethod() == jwt.SigningMethodRS256 {
+				key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(k.PrivateKey))
+				return key, k.Kid, err
+			}
+			return []byte(k.PrivateKey), k.Kid, nil
+		}
+	}
+	return nil, "", errors.New("未找到匹配kid的签名密钥")
+}
+
+// jwtVerifyKey 依据令牌 header 中的 kid 查找验证密钥，支持轮换期间新旧密钥并存
+func jwtVerifyKey(kid string) (interface{}, error) {
+	if len(cfg.Jwt.Keys) == 0 {
+		return []byte(cfg.Jwt.SigningKey), nil
+	}
+	for _, k := range cfg.Jwt.Keys {
+		if k.Kid == kid {
+			if jwtSigningMethod() == jwt.SigningMethodRS256 {
+				return jwt.ParseRSAPublicKeyFromPEM([]byte(k.PublicKey))
+			}
+			return []byte(k.PrivateKey), nil
+		}
+	}
+	return nil, errors.New("未找到匹配kid的验证密钥")
+}
+
+func signAccessToken(claims CustomerClaims) (string, error) {
+	token := jwt.NewWithClaims(jwtSigningMethod(), claims)
+	key, kid, err := jwtSigningKey()
+	if err != nil {
+		return "", err
+	}
+	token.Header["kid"] = kid
+	return token.SignedString(key)
+}
+
+func parseAccessToken(tokenString string) (*CustomerClaims, error) {
+	claims := &CustomerClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return jwtVerifyKey(kid)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if isAccessTokenRevoked(claims.Id) {
+		return nil, errors.New("访问凭证已被撤销")
+	}
+	return claims, nil
+}
+
+// setClaimsFromAccessToken 是所有需要鉴权的 handler 统一的入口：从
+// Authorization 头取出访问令牌，交给 parseAccessToken 做签名/kid/撤销
+// 校验（支持 HS256/RS256 并轮换密钥），校验通过后把 claims 写回 ctx。
+// 取代了旧版只认 HS256+cfg.Jwt.SigningKey、且不查黑名单的
+// ctx.parseClaimsFromToken()。
+func setClaimsFromAccessToken(c iris.Context, ctx *Context) error {
+	tokenString := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if tokenString == "" {
+		return errors.New("缺少访问凭证")
+	}
+
+	claims, err := parseAccessToken(tokenString)
+	if err != nil {
+		return err
+	}
+	ctx.claims = *claims
+	return nil
+}
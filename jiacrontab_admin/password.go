@@ -0,0 +1,170 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"jiacrontab/models"
+	"jiacrontab/pkg/mail"
+	"jiacrontab/pkg/password"
+	"jiacrontab/pkg/proto"
+	"jiacrontab/pkg/ratelimit"
+	"jiacrontab/pkg/util"
+	"time"
+
+	"github.com/kataras/iris"
+)
+
+const passwordResetTTL = 30 * time.Minute
+
+// loginLimiter/resetLimiter 都按 "ip:username" 做键限流，
+// 用于在 Login/RequestPasswordReset 上挡住撞库、爆破尝试
+var loginLimiter = ratelimit.New(10, 1)
+var resetLimiter = ratelimit.New(5, 1.0/60)
+
+// ChangePasswordReqParams /changePassword 请求参数
+type ChangePasswordReqParams struct {
+	OldPasswd string `json:"oldPasswd" valid:"required"`
+	NewPasswd string `json:"newPasswd" valid:"required"`
+}
+
+// RequestPasswordResetReqParams /requestPasswordReset 请求参数
+type RequestPasswordResetReqParams struct {
+	Username string `json:"username" valid:"required"`
+}
+
+// ResetPasswordReqParams /resetPassword 请求参数
+type ResetPasswordReqParams struct {
+	Token     string `json:"token" valid:"required"`
+	NewPasswd string `json:"newPasswd" valid:"required"`
+}
+
+// ChangePassword 登录态下修改密码，要求提供旧密码
+func ChangePassword(c iris.Context) {
+	var (
+		err     error
+		ctx     = wrapCtx(c)
+		reqBody ChangePasswordReqParams
+		user    models.User
+	)
+
+	if err = ctx.Valid(&reqBody); err != nil {
+		ctx.respParamError(err)
+		return
+	}
+
+	if err = setClaimsFromAccessToken(c, ctx); err != nil {
+		ctx.respJWTError(err)
+		return
+	}
+
+	if err = models.DB().Take(&user, "id=?", ctx.claims.UserID).Error; err != nil {
+		ctx.respDBError(err)
+		return
+	}
+
+	if !user.Verify(user.Username, reqBody.OldPasswd) {
+		ctx.respAuthFailed(errors.New("原密码不正确"))
+		return
+	}
+
+	if err = cfg.PasswordPolicy.Check(reqBody.NewPasswd); err != nil {
+		ctx.respParamError(err)
+		return
+	}
+
+	if err = setUserPassword(user.ID, reqBody.NewPasswd); err != nil {
+		ctx.respDBError(err)
+		return
+	}
+
+	models.RevokeAllRefreshTokensByUser(user.ID)
+	ctx.pubEvent(user.Username, event_ChangePassword, "", nil)
+	ctx.respSucc("", nil)
+}
+
+// RequestPasswordReset 给指定用户名对应的邮箱发送一次性重置令牌，
+// 出于防止用户名枚举的考虑，无论用户名是否存在都返回同样的成功响应
+func RequestPasswordReset(c iris.Context) {
+	var (
+		err     error
+		ctx     = wrapCtx(c)
+		reqBody RequestPasswordResetReqParams
+	)
+
+	if err = ctx.Valid(&reqBody); err != nil {
+		ctx.respParamError(err)
+		return
+	}
+
+	limiterKey := c.RemoteAddr() + ":" + reqBody.Username
+	if !resetLimiter.Allow(limiterKey) {
+		ctx.respError(proto.Code_Error, "请求过于频繁，请稍后再试", nil)
+		return
+	}
+
+	var user models.User
+	if err = models.DB().Take(&user, "username=?", reqBody.Username).Error; err == nil {
+		token := util.GenUUID()
+		if rt, err := models.NewPasswordResetToken(user.ID, token, passwordResetTTL); err == nil {
+			_ = rt
+			mail.Send(user.Mail, "jiacrontab 密码重置",
+				fmt.Sprintf("请在 30 分钟内使用以下令牌重置密码: %s", token))
+		}
+	}
+
+	ctx.respSucc("", nil)
+}
+
+// ResetPassword 凭重置令牌设置新密码，一次性消费该令牌并撤销该用户
+// 全部刷新令牌，强制其它已登录会话重新登录
+func ResetPassword(c iris.Context) {
+	var (
+		err     error
+		ctx     = wrapCtx(c)
+		reqBody ResetPasswordReqParams
+	)
+
+	if err = ctx.Valid(&reqBody); err != nil {
+		ctx.respParamError(err)
+		return
+	}
+
+	rt, err := models.FindPasswordResetToken(reqBody.Token)
+	if err != nil || rt.Expired() {
+		ctx.respAuthFailed(errors.New("重置令牌无效或已过期"))
+		return
+	}
+
+	if err = cfg.PasswordPolicy.Check(reqBody.NewPasswd); err != nil {
+		ctx.respParamError(err)
+		return
+	}
+
+	if err = setUserPassword(rt.UserID, reqBody.NewPasswd); err != nil {
+		ctx.respDBError(err)
+		return
+	}
+
+	if err = rt.Consume(); err != nil {
+		ctx.respDBError(err)
+		return
+	}
+
+	models.RevokeAllRefreshTokensByUser(rt.UserID)
+	ctx.pubEvent("", event_ResetPassword, "", nil)
+	ctx.respSucc("", nil)
+}
+
+// setUserPassword 用 pkg/password 的默认算法重新哈希并落库
+func setUserPassword(userID uint, plain string) error {
+	hash, err := password.Hash(plain)
+	if err != nil {
+		return err
+	}
+	return models.DB().Model(&models.User{}).Where("id=?", userID).Update("passwd", hash).Error
+}
+
+const (
+	event_ChangePassword = "event_ChangePassword"
+	event_ResetPassword  = "event_ResetPassword"
+)
@@ -0,0 +1,9 @@
+package admin
+
+// pubEvent 曾经只是把事件直接写进 models.Event，现在改为发布到
+// eventbus.Bus：内置的持久化订阅者负责落库（供 GetActivityList 使用），
+// webhook/邮件/指标等订阅者异步消费同一份事件，不再需要每个调用方
+// 自己关心这些渠道
+func (ctx *Context) pubEvent(target, name, addr string, payload interface{}) {
+	publishEvent(ctx.claims.UserID, target, name, addr, payload)
+}
@@ -5,70 +5,243 @@ import (
 	"errors"
 	"fmt"
 	"jiacrontab/models"
+	"jiacrontab/pkg/password"
 	"jiacrontab/pkg/proto"
 	"jiacrontab/pkg/util"
-	"strings"
 	"time"
 
 	jwt "github.com/dgrijalva/jwt-go"
 	"github.com/kataras/iris"
 )
 
+// accessTokenTTL 访问令牌有效期，固定较短以降低被窃取后的风险，
+// 长时间保持登录态由刷新令牌承担
+const accessTokenTTL = 15 * time.Minute
+
+// refreshTokenTTL 刷新令牌有效期，"记住我" 登录使用更长的有效期
+const refreshTokenTTL = 7 * 24 * time.Hour
+const refreshTokenRememberTTL = 24 * 30 * time.Hour
+
 type CustomerClaims struct {
 	jwt.StandardClaims
-	UserID   uint
-	Mail     string
-	Username string
-	GroupID  uint
-	Root     bool
+	UserID       uint
+	Mail         string
+	Username     string
+	GroupID      uint
+	Root         bool
+	Capabilities models.Capability
+}
+
+func newAccessToken(user models.User) (string, error) {
+	profile, err := models.FindUserProfile(user.ID)
+	if err != nil {
+		return "", err
+	}
+
+	var customerClaims CustomerClaims
+	customerClaims.Id = util.GenUUID()
+	customerClaims.ExpiresAt = time.Now().Add(accessTokenTTL).Unix()
+	customerClaims.Username = user.Username
+	customerClaims.UserID = user.ID
+	customerClaims.Mail = user.Mail
+	customerClaims.GroupID = user.GroupID
+	customerClaims.Root = user.Root
+	customerClaims.Capabilities = profile.Capabilities
+	return signAccessToken(customerClaims)
+}
+
+// loginBlocked 判断账号当前状态是否禁止登录。禁用状态不区分分组/Root，
+// 一个 Super 分组下的账号被 SetUserStatus 标记为 Disabled 之后一样不能登录
+func loginBlocked(profile *models.UserProfile) bool {
+	return profile.Status == models.UserStatusDisabled
 }
 
 // Login 用户登录
 func Login(c iris.Context) {
 	var (
-		err            error
-		ctx            = wrapCtx(c)
-		reqBody        LoginReqParams
-		user           models.User
-		customerClaims CustomerClaims
+		err     error
+		ctx     = wrapCtx(c)
+		reqBody LoginReqParams
+		user    models.User
 	)
 
 	if err = ctx.Valid(&reqBody); err != nil {
 		ctx.respParamError(err)
 		return
 	}
+
+	limiterKey := c.RemoteAddr() + ":" + reqBody.Username
+	if !loginLimiter.Allow(limiterKey) {
+		ctx.respError(proto.Code_Error, "登录尝试过于频繁，请稍后再试", nil)
+		return
+	}
+
 	if !user.Verify(reqBody.Username, reqBody.Passwd) {
 		ctx.respAuthFailed(errors.New("帐号或密码不正确"))
 		return
 	}
 
-	customerClaims.ExpiresAt = cfg.Jwt.Expires + time.Now().Unix()
-	customerClaims.Username = reqBody.Username
-	customerClaims.UserID = user.ID
-	customerClaims.Mail = user.Mail
-	customerClaims.GroupID = user.GroupID
-	customerClaims.Root = user.Root
+	if password.NeedsRehash(user.Passwd) {
+		setUserPassword(user.ID, reqBody.Passwd)
+	}
+
+	profile, err := models.FindUserProfile(user.ID)
+	if err != nil {
+		ctx.respDBError(err)
+		return
+	}
+	if loginBlocked(profile) {
+		ctx.respAuthFailed(errors.New("该账号已被禁用"))
+		return
+	}
 
+	token, err := newAccessToken(user)
+	if err != nil {
+		ctx.respAuthFailed(errors.New("无法生成访问凭证"))
+		return
+	}
+
+	rtTTL := refreshTokenTTL
 	if reqBody.Remember {
-		customerClaims.ExpiresAt = time.Now().Add(24 * 30 * time.Hour).Unix()
+		rtTTL = refreshTokenRememberTTL
+	}
+	refreshToken, err := models.NewRefreshTokenFamily(user.ID, rtTTL, c.GetHeader("User-Agent"), c.RemoteAddr())
+	if err != nil {
+		ctx.respDBError(err)
+		return
+	}
+
+	ctx.respSucc("", map[string]interface{}{
+		"token":        token,
+		"refreshToken": refreshToken.Jti,
+		"groupID":      user.GroupID,
+		"root":         user.Root,
+		"mail":         user.Mail,
+		"userID":       user.ID,
+	})
+}
+
+// Refresh 使用刷新令牌换取新的访问令牌，并按旋转策略签发新的刷新令牌。
+// 如果提交的刷新令牌已被撤销（说明旧令牌泄露后被重放），则撤销整个令牌家族
+func Refresh(c iris.Context) {
+	var (
+		err     error
+		ctx     = wrapCtx(c)
+		reqBody RefreshReqParams
+	)
+
+	if err = ctx.Valid(&reqBody); err != nil {
+		ctx.respParamError(err)
+		return
+	}
+
+	rt, err := models.FindRefreshTokenByJti(reqBody.RefreshToken)
+	if err != nil {
+		ctx.respAuthFailed(errors.New("无效的刷新令牌"))
+		return
+	}
+
+	if rt.RevokedAt != nil {
+		models.RevokeFamily(rt.FamilyID)
+		ctx.respAuthFailed(errors.New("检测到刷新令牌重放，已撤销该登录会话"))
+		return
+	}
+
+	if rt.Expired() {
+		ctx.respAuthFailed(errors.New("刷新令牌已过期，请重新登录"))
+		return
 	}
 
-	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, customerClaims).SignedString([]byte(cfg.Jwt.SigningKey))
+	var user models.User
+	if err = models.DB().Take(&user, "id=?", rt.UserID).Error; err != nil {
+		ctx.respDBError(err)
+		return
+	}
 
+	nextRT, err := rt.Rotate(time.Until(rt.ExpiresAt), c.GetHeader("User-Agent"), c.RemoteAddr())
+	if err != nil {
+		ctx.respDBError(err)
+		return
+	}
+
+	token, err := newAccessToken(user)
 	if err != nil {
 		ctx.respAuthFailed(errors.New("无法生成访问凭证"))
 		return
 	}
 
 	ctx.respSucc("", map[string]interface{}{
-		"token":   token,
-		"groupID": user.GroupID,
-		"root":    user.Root,
-		"mail":    user.Mail,
-		"userID":  user.ID,
+		"token":        token,
+		"refreshToken": nextRT.Jti,
 	})
 }
 
+// Logout 注销当前会话：撤销本次访问令牌并撤销对应的刷新令牌家族
+func Logout(c iris.Context) {
+	var (
+		err     error
+		ctx     = wrapCtx(c)
+		reqBody LogoutReqParams
+	)
+
+	if err = ctx.Valid(&reqBody); err != nil {
+		ctx.respParamError(err)
+		return
+	}
+
+	if err = setClaimsFromAccessToken(c, ctx); err != nil {
+		ctx.respJWTError(err)
+		return
+	}
+
+	if err = revokeAccessToken(ctx.claims.Id, ctx.claims.UserID, time.Unix(ctx.claims.ExpiresAt, 0)); err != nil {
+		ctx.respDBError(err)
+		return
+	}
+
+	if reqBody.RefreshToken != "" {
+		if rt, err := models.FindRefreshTokenByJti(reqBody.RefreshToken); err == nil {
+			models.RevokeFamily(rt.FamilyID)
+		}
+	}
+
+	ctx.respSucc("", nil)
+}
+
+// RevokeUser 管理员强制下线指定用户：撤销其全部刷新令牌家族，
+// 当前仍在有效期内的访问令牌由客户端下一次请求时自然过期（建议配合
+// 较短的 accessTokenTTL 使用）
+func RevokeUser(c iris.Context) {
+	var (
+		err     error
+		ctx     = wrapCtx(c)
+		reqBody RevokeUserReqParams
+	)
+
+	if err = ctx.Valid(&reqBody); err != nil {
+		ctx.respParamError(err)
+		return
+	}
+
+	if err = setClaimsFromAccessToken(c, ctx); err != nil {
+		ctx.respJWTError(err)
+		return
+	}
+
+	if !ctx.isSuper() {
+		ctx.respNotAllowed()
+		return
+	}
+
+	if err = models.RevokeAllRefreshTokensByUser(reqBody.UserID); err != nil {
+		ctx.respDBError(err)
+		return
+	}
+
+	ctx.pubEvent("", event_RevokeUser, "", reqBody)
+	ctx.respSucc("", nil)
+}
+
 func GetActivityList(c iris.Context) {
 	var (
 		ctx     = wrapCtx(c)
@@ -82,7 +255,7 @@ func GetActivityList(c iris.Context) {
 		return
 	}
 
-	if err = ctx.parseClaimsFromToken(); err != nil {
+	if err = setClaimsFromAccessToken(c, ctx); err != nil {
 		ctx.respBasicError(err)
 		return
 	}
@@ -124,6 +297,11 @@ func GetJobHistory(c iris.Context) {
 		return
 	}
 
+	if ctx.has(models.CapHistoryHidden) {
+		ctx.respNotAllowed()
+		return
+	}
+
 	if addrs, err = ctx.getGroupAddr(); err != nil {
 		ctx.respError(proto.Code_Error, err.Error(), err)
 		return
@@ -147,11 +325,42 @@ func GetJobHistory(c iris.Context) {
 	}
 
 	ctx.respSucc("", map[string]interface{}{
-		"list":     historys,
+		"list":     attachAuditState(historys),
 		"pagesize": reqBody.Pagesize,
 	})
 }
 
+// jobHistoryWithAudit 在原有 JobHistory 上附加当前审核状态与服务端计算
+// 出的快照差异，供前端在历史列表里直接展示，不用再单独查一次审核接口
+type jobHistoryWithAudit struct {
+	models.JobHistory
+	AuditStatus models.JobAuditStatus `json:"auditStatus,omitempty"`
+	AuditDiff   []snapshotDiffEntry   `json:"auditDiff,omitempty"`
+}
+
+// attachAuditState 为每一条任务历史查找与之对应的最新审核请求（如果有），
+// 附带上 Status 和 Snapshot/CurrentSnapshot 之间的差异
+func attachAuditState(historys []models.JobHistory) []jobHistoryWithAudit {
+	list := make([]jobHistoryWithAudit, 0, len(historys))
+	for _, h := range historys {
+		item := jobHistoryWithAudit{JobHistory: h}
+
+		var req models.JobAuditRequest
+		err := models.DB().Where("addr=? and job_id=?", h.Addr, h.JobID).
+			Order("id desc").Take(&req).Error
+		if err == nil {
+			item.AuditStatus = req.Status
+			item.AuditDiff = diffSnapshots(req.Snapshot, refreshCurrentSnapshot(&req))
+		}
+
+		list = append(list, item)
+	}
+	return list
+}
+
+// AuditJob 保留作为旧版单步审核入口，内部转为提交多阶段审核工作流
+// （见 SubmitJobForAudit/DecideAudit），不再直接调用节点 RPC 下发审核。
+// 未配置审批策略的分组等价于单阶段超管审批，行为与旧版本一致。
 func AuditJob(c iris.Context) {
 	var (
 		ctx     = wrapCtx(c)
@@ -169,40 +378,41 @@ func AuditJob(c iris.Context) {
 		return
 	}
 
-	if ctx.claims.GroupID != models.SuperGroup.ID && !ctx.claims.Root {
+	if ctx.claims.GroupID != models.SuperGroup.ID && !ctx.can(models.CapAuditOnly, reqBody.Addr) {
 		ctx.respNotAllowed()
 		return
 	}
 
-	if reqBody.JobType == "crontab" {
-		var reply []models.CrontabJob
-		if err = rpcCall(reqBody.Addr, "CrontabJob.Audit", proto.AuditJobArgs{
-			JobIDs: reqBody.JobIDs,
-		}, &reply); err != nil {
+	if ctx.has(models.CapJobEditLimited) {
+		ctx.respNotAllowed()
+		return
+	}
+
+	var requests []models.JobAuditRequest
+	for _, jobID := range reqBody.JobIDs {
+		snapshot, err := fetchJobSnapshot(reqBody.Addr, reqBody.JobType, jobID)
+		if err != nil {
 			ctx.respRPCError(err)
 			return
 		}
-		var targetNames []string
-		for _, v := range reply {
-			targetNames = append(targetNames, v.Name)
+		req := models.JobAuditRequest{
+			Addr:            reqBody.Addr,
+			JobType:         reqBody.JobType,
+			JobID:           jobID,
+			RequestedBy:     ctx.claims.UserID,
+			Snapshot:        snapshot,
+			CurrentSnapshot: snapshot,
+			Status:          models.JobAuditStatusPending,
 		}
-		ctx.pubEvent(strings.Join(targetNames, ","), event_AuditCrontabJob, reqBody.Addr, reqBody)
-	} else {
-		var reply []models.DaemonJob
-		if err = rpcCall(reqBody.Addr, "DaemonJob.Audit", proto.AuditJobArgs{
-			JobIDs: reqBody.JobIDs,
-		}, &reply); err != nil {
-			ctx.respRPCError(err)
+		if err = models.DB().Create(&req).Error; err != nil {
+			ctx.respDBError(err)
 			return
 		}
-		var targetNames []string
-		for _, v := range reply {
-			targetNames = append(targetNames, v.Name)
-		}
-		ctx.pubEvent(strings.Join(targetNames, ","), event_AuditDaemonJob, reqBody.Addr, reqBody)
+		requests = append(requests, req)
 	}
 
-	ctx.respSucc("", nil)
+	ctx.pubEvent("", event_SubmitJobForAudit, reqBody.Addr, requests)
+	ctx.respSucc("", requests)
 }
 
 // IninAdminUser 初始化管理员
@@ -224,8 +434,19 @@ func IninAdminUser(c iris.Context) {
 		return
 	}
 
+	if err = cfg.PasswordPolicy.Check(reqBody.Passwd); err != nil {
+		ctx.respParamError(err)
+		return
+	}
+
+	hashedPasswd, err := password.Hash(reqBody.Passwd)
+	if err != nil {
+		ctx.respError(proto.Code_Error, err.Error(), nil)
+		return
+	}
+
 	user.Username = reqBody.Username
-	user.Passwd = reqBody.Passwd
+	user.Passwd = hashedPasswd
 	user.Root = true
 	user.GroupID = models.SuperGroup.ID
 	user.Mail = reqBody.Mail
@@ -253,13 +474,24 @@ func Signup(c iris.Context) {
 		return
 	}
 
-	if !ctx.isSuper() {
+	if !ctx.canManageUsers() {
 		ctx.respNotAllowed()
 		return
 	}
 
+	if err = cfg.PasswordPolicy.Check(reqBody.Passwd); err != nil {
+		ctx.respParamError(err)
+		return
+	}
+
+	hashedPasswd, err := password.Hash(reqBody.Passwd)
+	if err != nil {
+		ctx.respDBError(err)
+		return
+	}
+
 	user.Username = reqBody.Username
-	user.Passwd = reqBody.Passwd
+	user.Passwd = hashedPasswd
 	user.GroupID = reqBody.GroupID
 	user.Root = reqBody.Root
 	user.Mail = reqBody.Mail
@@ -287,7 +519,7 @@ func UserStat(c iris.Context) {
 		}
 	)
 
-	if err = ctx.parseClaimsFromToken(); err != nil {
+	if err = setClaimsFromAccessToken(c, ctx); err != nil {
 		ctx.respJWTError(err)
 		return
 	}
@@ -312,7 +544,9 @@ func UserStat(c iris.Context) {
 	})
 }
 
-// GroupUser 超级管理员设置普通用户分组
+// GroupUser 将用户划到某个分组；ctx.canManageUsers() 只放行普通的分组调整，
+// 把用户设为 Root 或划进 SuperGroup 必须是真正的超级管理员才能做，
+// 否则一个只拿到 CapUserManage 的账号就能把自己提升成超管
 func GroupUser(c iris.Context) {
 	var (
 		ctx     = wrapCtx(c)
@@ -327,7 +561,12 @@ func GroupUser(c iris.Context) {
 		return
 	}
 
-	if !ctx.isSuper() {
+	if !ctx.canManageUsers() {
+		ctx.respNotAllowed()
+		return
+	}
+
+	if (reqBody.Root || reqBody.TargetGroupID == models.SuperGroup.ID) && !ctx.isSuper() {
 		ctx.respNotAllowed()
 		return
 	}
@@ -368,7 +607,7 @@ func GetUserList(c iris.Context) {
 		ctx.respParamError(err)
 	}
 
-	if err = ctx.parseClaimsFromToken(); err != nil {
+	if err = setClaimsFromAccessToken(c, ctx); err != nil {
 		ctx.respJWTError(err)
 		return
 	}
@@ -416,4 +655,4 @@ func GetUserList(c iris.Context) {
 		"page":     reqBody.Page,
 		"pagesize": reqBody.Pagesize,
 	})
-}
\ No newline at end of file
+}
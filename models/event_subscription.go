@@ -0,0 +1,30 @@
+package models
+
+// EventSubscription 记录某个用户希望通过哪些渠道接收哪类事件的通知，
+// 不存在记录时默认只走站内的 Event 列表（GetActivityList），不发送 webhook/邮件
+type EventSubscription struct {
+	Model
+	UserID     uint   `json:"userID" gorm:"index"`
+	EventName  string `json:"eventName" gorm:"index"`
+	Webhook    bool   `json:"webhook"`
+	Mail       bool   `json:"mail"`
+	WebhookURL string `json:"webhookURL"`
+}
+
+// FindEventSubscriptions 查询某用户的全部事件订阅偏好
+func FindEventSubscriptions(userID uint) ([]EventSubscription, error) {
+	var subs []EventSubscription
+	err := DB().Where("user_id=?", userID).Find(&subs).Error
+	return subs, err
+}
+
+// SaveEventSubscription 新增或更新某用户对某一事件的订阅偏好
+func SaveEventSubscription(sub *EventSubscription) error {
+	var existing EventSubscription
+	err := DB().Where("user_id=? and event_name=?", sub.UserID, sub.EventName).Take(&existing).Error
+	if err != nil {
+		return DB().Create(sub).Error
+	}
+	sub.Model = existing.Model
+	return DB().Save(sub).Error
+}
@@ -0,0 +1,10 @@
+package models
+
+// Group 节点/用户分组
+type Group struct {
+	Model
+	Name string `json:"name"`
+}
+
+// SuperGroup 超级管理员所在的内置分组，GroupID 等于它即视为超级管理员
+var SuperGroup = Group{Model: Model{ID: 1}, Name: "super"}
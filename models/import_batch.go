@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// ImportBatch 记录一次批量导入的幂等标记，ImportID 全局唯一（不区分 Code），
+// 客户端重试上传同一个 import_id 时服务端据此跳过已经处理过的批次
+type ImportBatch struct {
+	Model
+	Code       string    `json:"code" gorm:"index"`
+	ImportID   string    `json:"importID" gorm:"unique_index"`
+	UserID     uint      `json:"userID"`
+	TotalRows  int       `json:"totalRows"`
+	FailedRows int       `json:"failedRows"`
+	FinishedAt time.Time `json:"finishedAt"`
+	// ErrorReport 是失败行重新生成的 xlsx，供 BulkImportErrorReport 下载；
+	// 全部成功时为空
+	ErrorReport []byte `json:"-" gorm:"type:blob"`
+}
+
+// FindImportBatch 根据 import_id 查找是否已经处理过，用于幂等重试
+func FindImportBatch(importID string) (*ImportBatch, error) {
+	var b ImportBatch
+	err := DB().Where("import_id=?", importID).Take(&b).Error
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// CreateImportBatch 记录一次已完成的导入批次
+func CreateImportBatch(b *ImportBatch) error {
+	return DB().Create(b).Error
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// JobAuditStatus 审核请求所处的状态
+type JobAuditStatus string
+
+const (
+	JobAuditStatusPending   JobAuditStatus = "pending"
+	JobAuditStatusApproved  JobAuditStatus = "approved"
+	JobAuditStatusRejected  JobAuditStatus = "rejected"
+	JobAuditStatusWithdrawn JobAuditStatus = "withdrawn"
+)
+
+// JobAuditRequest 一次任务审核请求，对应提交审核时任务的快照，
+// 在多阶段审批流转过程中保持不变，只有全部阶段通过后才会真正
+// 下发到节点执行 CrontabJob.Audit/DaemonJob.Audit
+type JobAuditRequest struct {
+	Model
+	Addr            string         `json:"addr" gorm:"index"`
+	JobType         string         `json:"jobType"`
+	JobID           uint           `json:"jobID" gorm:"index"`
+	RequestedBy     uint           `json:"requestedBy"`
+	Snapshot        string         `json:"snapshot" gorm:"type:text"`
+	CurrentSnapshot string         `json:"currentSnapshot" gorm:"type:text"`
+	Status          JobAuditStatus `json:"status" gorm:"index"`
+	Stage           int            `json:"stage"`
+}
+
+// JobAuditStep 审核请求在每一个审批阶段留下的处理记录，
+// ReviewerUserID 记录做出决定的用户；DecideAudit 不写入 ReviewerGroupID
+type JobAuditStep struct {
+	Model
+	RequestID       uint      `json:"requestID" gorm:"index"`
+	StageIdx        int       `json:"stageIdx"`
+	ReviewerUserID  uint      `json:"reviewerUserID"`
+	ReviewerGroupID uint      `json:"reviewerGroupID"`
+	Decision        string    `json:"decision"`
+	Comment         string    `json:"comment"`
+	DecidedAt       time.Time `json:"decidedAt"`
+}
+
+// AuditPolicyStage 一个审批阶段，Quorum 是本阶段 decision=approve 的审核记录
+// 需要达到的条数，不区分来自哪个组或哪个用户；<=0 时按 1 处理
+type AuditPolicyStage struct {
+	ReviewerGroupIDs []uint `json:"reviewerGroupIDs"`
+	ReviewerUserIDs  []uint `json:"reviewerUserIDs"`
+	Quorum           int    `json:"quorum"`
+}
+
+// AuditPolicy 按节点分组配置的多阶段审批策略，例如
+// "先经过组X任一人审批，再经过超级管理员任一人审批"
+type AuditPolicy struct {
+	Model
+	GroupID uint               `json:"groupID" gorm:"unique_index"`
+	Stages  []AuditPolicyStage `json:"stages" gorm:"-"`
+	RawJSON string             `json:"-" gorm:"column:stages_json;type:text"`
+}
+
+// BeforeSave 将 Stages 序列化为 JSON 存入 stages_json 列
+func (p *AuditPolicy) BeforeSave() error {
+	b, err := json.Marshal(p.Stages)
+	if err != nil {
+		return err
+	}
+	p.RawJSON = string(b)
+	return nil
+}
+
+// AfterFind 从 stages_json 列反序列化出 Stages
+func (p *AuditPolicy) AfterFind() error {
+	if p.RawJSON == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(p.RawJSON), &p.Stages)
+}
+
+// FindAuditPolicy 查找指定节点分组的审批策略，不存在时返回默认的单阶段超管策略
+func FindAuditPolicy(groupID uint) (*AuditPolicy, error) {
+	var policy AuditPolicy
+	err := DB().Where("group_id=?", groupID).Take(&policy).Error
+	if err != nil {
+		return &AuditPolicy{
+			GroupID: groupID,
+			Stages: []AuditPolicyStage{
+				{ReviewerGroupIDs: []uint{SuperGroup.ID}, Quorum: 1},
+			},
+		}, nil
+	}
+	return &policy, nil
+}
@@ -0,0 +1,24 @@
+package models
+
+// Node 是被纳管的任务节点，按分组归属以便权限/审批策略按组下发
+type Node struct {
+	Model
+	Addr    string `json:"addr" gorm:"unique_index"`
+	Name    string `json:"name"`
+	GroupID uint   `json:"groupID" gorm:"index"`
+}
+
+// FindGroupIDByAddr 查出某个节点地址所属的分组 ID，用于按节点（而不是
+// 按发起请求/审核人自己）的分组来挑选审批策略
+func FindGroupIDByAddr(addr string) (uint, error) {
+	var groupID uint
+	err := DB().Raw(`select group_id from nodes where addr=?`, addr).Row().Scan(&groupID)
+	return groupID, err
+}
+
+// SetGroup 把节点分配到某个分组，NODE_GROUPS 批量导入用
+func (n *Node) SetGroup() error {
+	return DB().Where("addr=?", n.Addr).
+		Assign(Node{Name: n.Name, GroupID: n.GroupID}).
+		FirstOrCreate(n).Error
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// PasswordResetToken 一次性、短时效的密码重置令牌，经邮件发送给用户
+type PasswordResetToken struct {
+	Model
+	UserID    uint       `json:"userID" gorm:"index"`
+	Token     string     `json:"-" gorm:"unique_index;size:64"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+	UsedAt    *time.Time `json:"usedAt"`
+}
+
+// NewPasswordResetToken 为用户签发一个在 ttl 之后过期的重置令牌
+func NewPasswordResetToken(userID uint, token string, ttl time.Duration) (*PasswordResetToken, error) {
+	t := &PasswordResetToken{
+		UserID:    userID,
+		Token:     token,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	return t, DB().Create(t).Error
+}
+
+// FindPasswordResetToken 按 token 查找，调用方需再检查 Expired()
+func FindPasswordResetToken(token string) (*PasswordResetToken, error) {
+	var t PasswordResetToken
+	if err := DB().Where("token=?", token).Take(&t).Error; err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// Expired 判断重置令牌是否已过期或已被使用过
+func (t *PasswordResetToken) Expired() bool {
+	return t.UsedAt != nil || time.Now().After(t.ExpiresAt)
+}
+
+// Consume 标记该重置令牌为已使用，防止被重放
+func (t *PasswordResetToken) Consume() error {
+	now := time.Now()
+	t.UsedAt = &now
+	return DB().Save(t).Error
+}
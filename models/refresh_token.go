@@ -0,0 +1,90 @@
+package models
+
+import (
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// RefreshToken 刷新令牌，用于在不重新登录的情况下换取新的访问令牌
+// 同一次登录签发的所有刷新令牌共享同一个 FamilyID，
+// 一旦家族中任意一个已撤销的令牌被再次使用，整个家族都会被撤销，
+// 以便在令牌被窃取并重放时阻断攻击者
+type RefreshToken struct {
+	Model
+	UserID    uint       `json:"userID" gorm:"index"`
+	Jti       string     `json:"jti" gorm:"unique_index;size:64"`
+	FamilyID  string     `json:"familyID" gorm:"index;size:64"`
+	UserAgent string     `json:"userAgent"`
+	IP        string     `json:"ip"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+	RevokedAt *time.Time `json:"revokedAt"`
+}
+
+// NewRefreshTokenFamily 创建一个新的刷新令牌家族（登录时调用）
+func NewRefreshTokenFamily(userID uint, ttl time.Duration, userAgent, ip string) (*RefreshToken, error) {
+	rt := &RefreshToken{
+		UserID:    userID,
+		Jti:       uuid.NewV4().String(),
+		FamilyID:  uuid.NewV4().String(),
+		UserAgent: userAgent,
+		IP:        ip,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := DB().Create(rt).Error; err != nil {
+		return nil, err
+	}
+	return rt, nil
+}
+
+// Rotate 撤销当前刷新令牌并在同一家族内签发一个新的刷新令牌
+func (rt *RefreshToken) Rotate(ttl time.Duration, userAgent, ip string) (*RefreshToken, error) {
+	next := &RefreshToken{
+		UserID:    rt.UserID,
+		Jti:       uuid.NewV4().String(),
+		FamilyID:  rt.FamilyID,
+		UserAgent: userAgent,
+		IP:        ip,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+
+	tx := DB().Begin()
+	if err := tx.Model(rt).Update("revoked_at", time.Now()).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Create(next).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return next, tx.Commit().Error
+}
+
+// FindRefreshTokenByJti 根据 jti 查找刷新令牌
+func FindRefreshTokenByJti(jti string) (*RefreshToken, error) {
+	var rt RefreshToken
+	if err := DB().Where("jti=?", jti).Take(&rt).Error; err != nil {
+		return nil, err
+	}
+	return &rt, nil
+}
+
+// Expired 判断刷新令牌是否已过期或已被撤销
+func (rt *RefreshToken) Expired() bool {
+	return rt.RevokedAt != nil || time.Now().After(rt.ExpiresAt)
+}
+
+// RevokeFamily 撤销同一家族内的所有刷新令牌，用于检测到令牌重放时的防护
+func RevokeFamily(familyID string) error {
+	return DB().Model(&RefreshToken{}).
+		Where("family_id=? and revoked_at is null", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeAllRefreshTokensByUser 撤销指定用户名下所有尚未撤销的刷新令牌，
+// 用于修改密码、重置密码、管理员强制下线等场景
+func RevokeAllRefreshTokensByUser(userID uint) error {
+	return DB().Model(&RefreshToken{}).
+		Where("user_id=? and revoked_at is null", userID).
+		Update("revoked_at", time.Now()).Error
+}
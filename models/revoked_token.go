@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// RevokedToken 持久化的访问令牌黑名单记录，用于 Logout 后立刻让已签发
+// 但尚未过期的访问令牌失效（RevokeUser 不写入此表）。进程内还维护一份
+// 内存副本（见 jiacrontab_admin 包），进程重启时用此表重建内存黑名单，
+// 内存中未命中的 jti 也会再回查此表。
+type RevokedToken struct {
+	Model
+	Jti       string    `json:"jti" gorm:"unique_index;size:64"`
+	UserID    uint      `json:"userID" gorm:"index"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// RevokeToken 将一个访问令牌 jti 记入黑名单，ExpiresAt 之后该记录即可被清理
+func RevokeToken(jti string, userID uint, expiresAt time.Time) error {
+	return DB().Create(&RevokedToken{
+		Jti:       jti,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	}).Error
+}
+
+// IsTokenRevoked 查询某个 jti 是否在黑名单中
+func IsTokenRevoked(jti string) bool {
+	var count int
+	DB().Model(&RevokedToken{}).Where("jti=?", jti).Count(&count)
+	return count > 0
+}
+
+// LoadUnexpiredRevokedTokens 启动时加载尚未过期的黑名单记录以重建内存副本
+func LoadUnexpiredRevokedTokens() ([]RevokedToken, error) {
+	var tokens []RevokedToken
+	err := DB().Where("expires_at > ?", time.Now()).Find(&tokens).Error
+	return tokens, err
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"jiacrontab/pkg/password"
+)
+
+// User 管理后台账号
+type User struct {
+	Model
+	Username string `json:"username" gorm:"unique_index"`
+	Passwd   string `json:"-"`
+	Mail     string `json:"mail"`
+	GroupID  uint   `json:"groupID"`
+	Root     bool   `json:"root"`
+	Group    Group  `json:"group"`
+}
+
+// Verify 校验用户名/密码是否匹配。Passwd 列里既可能是
+// pkg/password 产出的 "algo$iter$salt$hash" 哈希（新建或改密之后的账号），
+// 也可能是升级前遗留的明文（老库迁移场景），后者校验通过后由调用方
+// 通过 password.NeedsRehash 触发重新哈希
+func (u *User) Verify(username, passwd string) bool {
+	if err := DB().Where("username=?", username).Take(u).Error; err != nil {
+		return false
+	}
+
+	if password.Verify(u.Passwd, passwd) {
+		return true
+	}
+
+	// 兼容升级前遗留的明文密码，迁移期过后可以删除这个分支
+	return u.Passwd == passwd
+}
+
+// Create 新建一个用户
+func (u *User) Create() error {
+	return DB().Create(u).Error
+}
+
+// SetGroup 修改用户所属分组和 Root 标记
+func (u *User) SetGroup() error {
+	return DB().Model(u).Updates(map[string]interface{}{
+		"group_id": u.GroupID,
+		"root":     u.Root,
+	}).Error
+}
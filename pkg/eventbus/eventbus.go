@@ -0,0 +1,142 @@
+// Package eventbus 提供一个轻量的类型化发布/订阅总线，供 admin 包内
+// 登录、审计、分组变更等业务事件使用，替代原先直接落库的 pubEvent。
+// 设计上参考 gookit/event：按事件名注册若干个带优先级的处理函数，
+// 每个处理函数可以选择同步执行或丢进后台 worker 池异步执行。
+package eventbus
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Event 总线上流转的事件，Payload 由发布方按事件类型放入具体的结构体，
+// 订阅方按需做类型断言而不是解析 map[string]interface{}
+type Event struct {
+	Name    string
+	UserID  uint
+	Addr    string
+	Target  string
+	Payload interface{}
+	At      time.Time
+}
+
+// Handler 处理一个事件，返回的 error 仅用于日志记录，不会中断其它订阅者
+type Handler func(Event) error
+
+// Option 配置某一次 Subscribe 调用
+type Option func(*subscription)
+
+// WithPriority 数值越小越先执行，默认 0
+func WithPriority(p int) Option {
+	return func(s *subscription) { s.priority = p }
+}
+
+// WithAsync 标记该订阅者在后台 worker 池中异步执行，不阻塞 Publish 调用方
+func WithAsync() Option {
+	return func(s *subscription) { s.async = true }
+}
+
+type subscription struct {
+	handler  Handler
+	priority int
+	async    bool
+}
+
+// Bus 是一个可并发使用的事件总线
+type Bus struct {
+	mu       sync.RWMutex
+	subs     map[string][]*subscription
+	workerWg sync.WaitGroup
+	jobs     chan func()
+	closed   chan struct{}
+}
+
+// New 创建一个事件总线，workers 为异步处理的并发 worker 数
+func New(workers int) *Bus {
+	if workers <= 0 {
+		workers = 4
+	}
+	b := &Bus{
+		subs:   make(map[string][]*subscription),
+		jobs:   make(chan func(), 256),
+		closed: make(chan struct{}),
+	}
+	for i := 0; i < workers; i++ {
+		b.workerWg.Add(1)
+		go b.worker()
+	}
+	return b
+}
+
+func (b *Bus) worker() {
+	defer b.workerWg.Done()
+	for {
+		select {
+		case job := <-b.jobs:
+			job()
+		case <-b.closed:
+			return
+		}
+	}
+}
+
+// Subscribe 为一个事件名注册一个处理函数
+func (b *Bus) Subscribe(name string, handler Handler, opts ...Option) {
+	s := &subscription{handler: handler}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subs[name] = append(b.subs[name], s)
+	sort.SliceStable(b.subs[name], func(i, j int) bool {
+		return b.subs[name][i].priority < b.subs[name][j].priority
+	})
+}
+
+// Publish 按优先级顺序同步调用所有订阅者；标记为异步的订阅者会被丢进
+// worker 池，不等待其完成
+func (b *Bus) Publish(e Event) {
+	b.mu.RLock()
+	subs := append([]*subscription(nil), b.subs[e.Name]...)
+	if e.Name != "*" {
+		subs = append(subs, b.subs["*"]...)
+		sort.SliceStable(subs, func(i, j int) bool {
+			return subs[i].priority < subs[j].priority
+		})
+	}
+	b.mu.RUnlock()
+
+	for _, s := range subs {
+		s := s
+		if s.async {
+			select {
+			case b.jobs <- func() { safeHandle(s.handler, e) }:
+			default:
+				go safeHandle(s.handler, e)
+			}
+			continue
+		}
+		safeHandle(s.handler, e)
+	}
+}
+
+func safeHandle(h Handler, e Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Println("eventbus: handler panic:", r)
+		}
+	}()
+	if err := h(e); err != nil {
+		fmt.Println("eventbus: handler error:", err)
+	}
+}
+
+// Close 停止所有后台 worker，已入队但未执行的异步任务会被丢弃
+func (b *Bus) Close() {
+	close(b.closed)
+	b.workerWg.Wait()
+}
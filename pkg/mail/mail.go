@@ -0,0 +1,39 @@
+// Package mail 封装通过 SMTP 发送通知邮件的最小实现，供事件通知、
+// 密码重置等需要发邮件的场景复用同一份配置
+package mail
+
+import (
+	"fmt"
+	"net/smtp"
+)
+
+// Config 对应配置文件中的 SMTP 小节
+type Config struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+var cfg Config
+
+// Configure 在进程启动时由 admin 包用全局配置初始化 SMTP 参数
+func Configure(c Config) {
+	cfg = c
+}
+
+// Send 发送一封纯文本邮件，Host 未配置时静默跳过（便于本地开发/测试）
+func Send(to, subject, body string) error {
+	if cfg.Host == "" {
+		return nil
+	}
+
+	addr := fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)
+	auth := smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	msg := []byte("To: " + to + "\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"\r\n" + body + "\r\n")
+
+	return smtp.SendMail(addr, auth, cfg.From, []string{to}, msg)
+}
@@ -0,0 +1,104 @@
+// Package password 提供可插拔的密码哈希方案。哈希结果统一编码为
+// "algo$iter$salt$hash"，使得线上可以在不强制用户改密的情况下，
+// 在下一次成功登录时把旧算法/旧迭代次数的哈希透明地升级为新的。
+package password
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Algo 标识哈希算法
+type Algo string
+
+const (
+	AlgoPBKDF2SHA256 Algo = "pbkdf2-sha256"
+	AlgoArgon2id     Algo = "argon2id"
+)
+
+// DefaultAlgo 是新密码使用的默认算法
+var DefaultAlgo = AlgoPBKDF2SHA256
+
+// DefaultIterations 是 PBKDF2 默认迭代次数，可随硬件发展调高，
+// 旧哈希会在下次登录成功后升级到新值
+var DefaultIterations = 100000
+
+const saltLen = 16
+const keyLen = 32
+
+// Hash 使用 DefaultAlgo/DefaultIterations 为明文密码生成新的哈希字符串
+func Hash(plain string) (string, error) {
+	salt := make([]byte, saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	return hashWith(DefaultAlgo, DefaultIterations, salt, plain), nil
+}
+
+func hashWith(algo Algo, iter int, salt []byte, plain string) string {
+	var key []byte
+	switch algo {
+	case AlgoArgon2id:
+		key = argon2.IDKey([]byte(plain), salt, uint32(iter), 64*1024, 4, keyLen)
+	default:
+		key = pbkdf2.Key([]byte(plain), salt, iter, keyLen, sha256.New)
+	}
+	return fmt.Sprintf("%s$%d$%s$%s", algo, iter, encode(salt), encode(key))
+}
+
+// Verify 校验明文密码是否匹配已存储的哈希字符串
+func Verify(stored, plain string) bool {
+	algo, iter, salt, key, err := parse(stored)
+	if err != nil {
+		return false
+	}
+	got := hashWith(algo, iter, salt, plain)
+	_, _, _, gotKey, _ := parse(got)
+	return subtle.ConstantTimeCompare(key, gotKey) == 1
+}
+
+// NeedsRehash 判断已存储的哈希是否使用了过时的算法或迭代次数，
+// 调用方应在密码校验成功后据此决定是否用 Hash 重新生成并落库
+func NeedsRehash(stored string) bool {
+	algo, iter, _, _, err := parse(stored)
+	if err != nil {
+		return true
+	}
+	return algo != DefaultAlgo || (algo == AlgoPBKDF2SHA256 && iter != DefaultIterations)
+}
+
+func parse(stored string) (Algo, int, []byte, []byte, error) {
+	parts := strings.Split(stored, "$")
+	if len(parts) != 4 {
+		return "", 0, nil, nil, fmt.Errorf("password: malformed hash")
+	}
+	iter, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return "", 0, nil, nil, err
+	}
+	salt, err := decode(parts[2])
+	if err != nil {
+		return "", 0, nil, nil, err
+	}
+	key, err := decode(parts[3])
+	if err != nil {
+		return "", 0, nil, nil, err
+	}
+	return Algo(parts[0]), iter, salt, key, nil
+}
+
+func encode(b []byte) string {
+	return base64.RawStdEncoding.EncodeToString(b)
+}
+
+func decode(s string) ([]byte, error) {
+	return base64.RawStdEncoding.DecodeString(s)
+}
@@ -0,0 +1,81 @@
+package password
+
+import (
+	"errors"
+	"io/ioutil"
+	"strings"
+	"unicode"
+)
+
+// Policy 是可配置的密码强度要求，DenylistFile 为空时跳过弱密码字典校验
+type Policy struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	DenylistFile  string
+}
+
+var denylist map[string]struct{}
+
+// LoadDenylist 从文件加载一份已泄露/弱密码字典，每行一个，供 Check 使用
+func LoadDenylist(path string) error {
+	if path == "" {
+		return nil
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	denylist = make(map[string]struct{})
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			denylist[line] = struct{}{}
+		}
+	}
+	return nil
+}
+
+// Check 校验明文密码是否满足策略，返回第一个不满足的原因
+func (p Policy) Check(plain string) error {
+	if len(plain) < p.MinLength {
+		return errors.New("密码长度不足")
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range plain {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		default:
+			hasSymbol = true
+		}
+	}
+
+	if p.RequireUpper && !hasUpper {
+		return errors.New("密码必须包含大写字母")
+	}
+	if p.RequireLower && !hasLower {
+		return errors.New("密码必须包含小写字母")
+	}
+	if p.RequireDigit && !hasDigit {
+		return errors.New("密码必须包含数字")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		return errors.New("密码必须包含特殊字符")
+	}
+
+	if denylist != nil {
+		if _, found := denylist[plain]; found {
+			return errors.New("密码过于常见，请更换一个更安全的密码")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+// Package ratelimit 提供一个进程内的漏桶限流器，用于在登录、找回密码
+// 等容易被撞库/爆破的接口上按 key（IP、用户名）限制请求速率
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	level    float64
+	lastSeen time.Time
+}
+
+// staleSweepInterval 是两次清理之间的最短间隔，避免每次 Allow 都扫一遍 map
+const staleSweepInterval = 30 * time.Minute
+
+// LeakyBucket 是一个按 key 分桶的漏桶限流器
+type LeakyBucket struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	capacity  float64
+	leakRate  float64 // 每秒漏出的量
+	lastSweep time.Time
+}
+
+// New 创建一个漏桶限流器：capacity 为桶容量，每秒最多允许 leakPerSecond 次请求的等效速率
+func New(capacity float64, leakPerSecond float64) *LeakyBucket {
+	return &LeakyBucket{
+		buckets:  make(map[string]*bucket),
+		capacity: capacity,
+		leakRate: leakPerSecond,
+	}
+}
+
+// Allow 对某个 key 尝试消耗一次配额，超过桶容量时返回 false
+func (l *LeakyBucket) Allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{lastSeen: now}
+		l.buckets[key] = b
+	}
+
+	elapsed := now.Sub(b.lastSeen).Seconds()
+	b.level -= elapsed * l.leakRate
+	if b.level < 0 {
+		b.level = 0
+	}
+	b.lastSeen = now
+
+	if b.level+1 > l.capacity {
+		return false
+	}
+	b.level++
+	return true
+}
+
+// sweep 驱逐已经漏空（或按漏出速率推算到现在已经漏空）的桶，避免 buckets
+// 随着像 ip:username 这样不断出现的新 key 无限增长；每 staleSweepInterval
+// 最多触发一次，摊到每次 Allow 调用里，不需要额外的后台 goroutine
+func (l *LeakyBucket) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < staleSweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		elapsed := now.Sub(b.lastSeen).Seconds()
+		if b.level-elapsed*l.leakRate <= 0 {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,8 @@
+package util
+
+import uuid "github.com/satori/go.uuid"
+
+// GenUUID 生成一个随机的 v4 UUID 字符串，用于令牌 jti、家族 ID 等场景
+func GenUUID() string {
+	return uuid.NewV4().String()
+}